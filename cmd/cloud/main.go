@@ -16,6 +16,8 @@ limitations under the License.
 package main
 
 import (
+	"context"
+	"errors"
 	"os"
 	"os/signal"
 	"syscall"
@@ -25,8 +27,12 @@ import (
 
 	"github.com/rewardenv/reward-cloud-cli/cmd/root"
 	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
 )
 
+// exitCodeInterrupted is the exit status of the commands stopped by an interrupt.
+const exitCodeInterrupted = 130
+
 var (
 	APPNAME       = "cloud"
 	PARENTAPPNAME = "reward"
@@ -34,32 +40,39 @@ var (
 )
 
 func main() {
-	sig := make(chan os.Signal, 1)
-	signal.Notify(
-		sig,
-		syscall.SIGINT,
-		syscall.SIGTERM,
-		syscall.SIGQUIT,
-	)
-
 	app := config.New(APPNAME, PARENTAPPNAME, VERSION)
 
 	cobra.OnInitialize(func() {
 		app.Init()
+		app.SweepTmpFiles()
 	})
 
-	go func() {
-		<-sig
+	// Interrupts cancel the context of the running command instead of exiting, so the commands can stop gracefully
+	// (or ignore them, e.g. while a database client is running) and the temporary files are removed below.
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM, syscall.SIGQUIT)
 
-		if err := app.Cleanup(); err != nil {
-			os.Exit(1)
-		}
+	err := root.NewCmdRoot(app).ExecuteContext(ctx)
 
-		os.Exit(0)
-	}()
+	interrupted := ctx.Err() != nil
+
+	stop()
+
+	if cleanupErr := app.Cleanup(); cleanupErr != nil {
+		log.Debugf("Cannot remove temporary files: %s", cleanupErr)
+	}
+
+	var exitErr *logic.ExitCodeError
+	if errors.As(err, &exitErr) {
+		os.Exit(exitErr.Code)
+	}
 
-	err := root.NewCmdRoot(app).Execute()
 	if err != nil {
+		if interrupted && errors.Is(err, context.Canceled) {
+			os.Exit(exitCodeInterrupted)
+		}
+
 		log.Error(err)
+
+		os.Exit(1)
 	}
 }
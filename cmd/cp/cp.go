@@ -0,0 +1,46 @@
+package cp
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdCp(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "cp <src> <dst>",
+			Short: "copy files between the local machine and the environment",
+			Long: `copy files and directories between the local machine and the containers of the environment
+
+Remote paths are written as component:path, e.g.:
+  cp main:/var/www/html/var/log/system.log .
+  cp app/etc/env.php main:/var/www/html/app/etc/env.php
+  cp db:/tmp/dump.sql ./dumps/
+
+Directories are copied recursively. If the destination is an existing directory, the source is
+copied into it. Use ./ in front of local paths containing a colon. The container needs tar.`,
+			Args: cobra.ExactArgs(2),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewCopyClient(app).RunCmdCopy(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "copying")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("container", "", "container to use (default: the main container of the component)")
+	_ = cmd.App.BindPFlag("cp_container", cmd.Flags().Lookup("container"))
+
+	cmd.Flags().String("pod", "", "pod to use, overrides the component")
+	_ = cmd.App.BindPFlag("cp_pod", cmd.Flags().Lookup("pod"))
+
+	return cmd
+}
@@ -0,0 +1,54 @@
+package credential
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdCredential(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "credential",
+			Short: "print the kubernetes exec credential of a cluster",
+			Long: `print the kubernetes exec credential of a cluster
+
+This command is called by kubectl as the exec credential plugin of the generated kubeconfigs. It logs in
+using the OIDC authorization code flow with PKCE in the browser or the device code flow on headless
+machines, and caches and refreshes the tokens in the cache directory.
+
+The client secret of the cluster is cached in the cache directory when the kubeconfig is generated. It can
+be overridden using the REWARD_CLOUD_OIDC_CLIENT_SECRET environment variable.`,
+			Hidden: true,
+			Args:   cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewCredentialClient(app).RunCmdCredential(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "getting credential")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Int32("cluster-id", 0, "cluster id")
+	_ = cmd.App.BindPFlag("credential_cluster_id", cmd.Flags().Lookup("cluster-id"))
+
+	cmd.Flags().String("issuer-url", "", "OIDC issuer url")
+	_ = cmd.App.BindPFlag("credential_issuer_url", cmd.Flags().Lookup("issuer-url"))
+
+	cmd.Flags().String("client-id", "", "OIDC client id")
+	_ = cmd.App.BindPFlag("credential_client_id", cmd.Flags().Lookup("client-id"))
+
+	cmd.Flags().String("flow", "auto", "login flow (options: auto, browser, device)")
+	_ = cmd.App.BindPFlag(fmt.Sprintf("%s_oidc_flow", cmd.App.ConfigPrefix()), cmd.Flags().Lookup("flow"))
+
+	return cmd
+}
@@ -0,0 +1,49 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvDeployments(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "deployments",
+			Short: "list past deployments",
+			Long: `list past deployments of the environment with their ref, author, duration and result
+
+The API does not keep a deployment history, only the deployments started with "env build-and-deploy"
+on this machine are listed. To redeploy a previous ref, run "env build-and-deploy --ref <commit>".`,
+			Args: cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvDeployments(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "listing deployments")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("all", false, "list the deployments of all environments")
+	_ = cmd.App.BindPFlag("env_deployments_all", cmd.Flags().Lookup("all"))
+
+	cmd.Flags().Int("limit", 20, "maximum number of deployments to list (0 means no limit)")
+	_ = cmd.App.BindPFlag("env_deployments_limit", cmd.Flags().Lookup("limit"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("env_deployments_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
@@ -0,0 +1,60 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvDiff(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "diff <contextA> [contextB]",
+			Short: "compare the configuration of two environments",
+			Long: `compare the configuration of the environments of two contexts
+
+The environment settings, the project type version, the cluster, the access endpoints and the
+environment variables are compared. With a single context the current context is compared to it.
+
+Volatile fields (ids, timestamps, authors and references to exported data) are ignored unless
+--include-volatile is used. Further fields can be ignored using --ignore with a field name
+(e.g. cpu) or a path (e.g. environment.environmentComponent).`,
+			Args: cobra.RangeArgs(1, 2),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvDiff(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "comparing environments")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().StringP("output", "o", logic.DiffFormatUnified,
+		"output format (options: unified, side-by-side, json, yaml)")
+	_ = cmd.App.BindPFlag("env_diff_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().StringSlice("ignore", nil, "fields or paths to ignore")
+	_ = cmd.App.BindPFlag("env_diff_ignore", cmd.Flags().Lookup("ignore"))
+
+	cmd.Flags().Bool("include-volatile", false, "compare volatile fields like ids and timestamps")
+	_ = cmd.App.BindPFlag("env_diff_include_volatile", cmd.Flags().Lookup("include-volatile"))
+
+	cmd.Flags().Bool("reveal", false, "compare secret values in plain text")
+	_ = cmd.App.BindPFlag("env_diff_reveal", cmd.Flags().Lookup("reveal"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("env_diff_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
@@ -28,8 +28,20 @@ func NewCmdEnv(app *config.App) *cmdpkg.Command {
 
 	cmd.AddCommands(
 		NewCmdEnvBuildAndDeploy(app),
+		NewCmdEnvDeployments(app),
+		NewCmdEnvDiff(app),
 		NewCmdEnvExportDB(app),
 		NewCmdEnvExportMedia(app),
+		NewCmdEnvExports(app),
+		NewCmdEnvImportDB(app),
+		NewCmdEnvImportMedia(app),
+		NewCmdEnvList(app),
+		NewCmdEnvStatus(app),
+		NewCmdEnvCreate(app),
+		NewCmdEnvDelete(app),
+		NewCmdEnvStart(app),
+		NewCmdEnvStop(app),
+		NewCmdEnvVars(app),
 	)
 
 	return cmd
@@ -40,7 +52,17 @@ func NewCmdEnvBuildAndDeploy(app *config.App) *cmdpkg.Command {
 		Command: &cobra.Command{
 			Use:   "build-and-deploy",
 			Short: "build and deploy environment",
-			Long:  `build and deploy environment`,
+			Long: `build and deploy environment
+
+By default the branch checked out in the current directory is deployed (or the commit if HEAD is detached).
+As the build runs from the remote repository, the local commit must be pushed first. Use --ref to deploy
+a branch, tag or commit sha instead, e.g. a previous commit listed by "env deployments", or --ref=server
+to deploy the ref configured for the environment. Outside of a git repository without --ref, the ref
+configured for the environment is deployed.
+
+The ref is passed to the build in the environment variable configured by branch_env_var, which is restored
+when the build finished.`,
+			Args: cobra.NoArgs,
 			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
 				[]string, cobra.ShellCompDirective,
 			) {
@@ -58,6 +80,13 @@ func NewCmdEnvBuildAndDeploy(app *config.App) *cmdpkg.Command {
 		App: app,
 	}
 
+	cmd.Flags().String("ref", "HEAD", "git branch, tag or commit sha to deploy, HEAD for the local git checkout "+
+		"or server for the ref configured for the environment")
+	_ = cmd.App.BindPFlag("build_and_deploy_ref", cmd.Flags().Lookup("ref"))
+
+	cmd.Flags().Bool("skip-push-check", false, "do not check if the local git HEAD has been pushed")
+	_ = cmd.App.BindPFlag("build_and_deploy_skip_push_check", cmd.Flags().Lookup("skip-push-check"))
+
 	return cmd
 }
 
@@ -87,6 +116,16 @@ func NewCmdEnvExportDB(app *config.App) *cmdpkg.Command {
 	cmd.Flags().Bool("strip-database", false, "remove sensitve data from database dump")
 	_ = cmd.App.BindPFlag("strip_database", cmd.Flags().Lookup("strip-database"))
 
+	cmd.Flags().Bool("download", false, "download the exported database")
+	_ = cmd.App.BindPFlag("export_db_download", cmd.Flags().Lookup("download"))
+
+	cmd.Flags().StringP("output", "o", "", "download the exported database to this path")
+	_ = cmd.App.BindPFlag("export_db_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().String("strip-profile", "",
+		"apply the named strip profile of the config file to the downloaded dump (implies --download)")
+	_ = cmd.App.BindPFlag("export_db_strip_profile", cmd.Flags().Lookup("strip-profile"))
+
 	return cmd
 }
 
@@ -113,5 +152,11 @@ func NewCmdEnvExportMedia(app *config.App) *cmdpkg.Command {
 		App: app,
 	}
 
+	cmd.Flags().Bool("download", false, "download the exported media")
+	_ = cmd.App.BindPFlag("export_media_download", cmd.Flags().Lookup("download"))
+
+	cmd.Flags().StringP("output", "o", "", "download the exported media to this path")
+	_ = cmd.App.BindPFlag("export_media_output", cmd.Flags().Lookup("output"))
+
 	return cmd
 }
@@ -0,0 +1,191 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvExports(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "exports",
+			Short: "manage exported data",
+			Long:  `manage exported data`,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return cmd.Help() //nolint:wrapcheck
+			},
+		},
+		App: app,
+	}
+
+	cmd.AddCommands(
+		NewCmdEnvExportsList(app),
+		NewCmdEnvExportsShow(app),
+		NewCmdEnvExportsDelete(app),
+		NewCmdEnvExportsDownload(app),
+	)
+
+	return cmd
+}
+
+func NewCmdEnvExportsList(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "list",
+			Short:   "list exported data",
+			Long:    `list exported data of the environment`,
+			Aliases: []string{"ls"},
+			Args:    cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvExportsList(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "listing exported data")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("type", "", "filter by the type of the export (options: database, media)")
+	_ = cmd.App.BindPFlag("exports_list_type", cmd.Flags().Lookup("type"))
+
+	cmd.Flags().String("since", "", "list exports created on or after this date (YYYY-MM-DD or RFC3339)")
+	_ = cmd.App.BindPFlag("exports_list_since", cmd.Flags().Lookup("since"))
+
+	cmd.Flags().String("until", "", "list exports created on or before this date "+
+		"(YYYY-MM-DD for the whole day or RFC3339)")
+	_ = cmd.App.BindPFlag("exports_list_until", cmd.Flags().Lookup("until"))
+
+	cmd.Flags().String("environment", "", "environment id (default: the environment of the current context)")
+	_ = cmd.App.BindPFlag("exports_list_environment", cmd.Flags().Lookup("environment"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("exports_list_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
+
+func NewCmdEnvExportsShow(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "show <id|latest>",
+			Short: "show exported data",
+			Long:  `show exported data by its id or the latest export of the given type`,
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return []string{"latest"}, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvExportsShow(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "showing exported data")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("type", "database", "type of the export when showing the latest one (options: database, media)")
+	_ = cmd.App.BindPFlag("exports_show_type", cmd.Flags().Lookup("type"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("exports_show_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
+
+func NewCmdEnvExportsDelete(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "delete [id]",
+			Short:   "delete exported data",
+			Long:    `delete exported data by its id or every export older than the given age`,
+			Aliases: []string{"rm"},
+			Args:    cobra.MaximumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvExportsDelete(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "deleting exported data")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("older-than", "", "delete every export older than this age (e.g. 30d, 2w, 12h)")
+	_ = cmd.App.BindPFlag("exports_delete_older_than", cmd.Flags().Lookup("older-than"))
+
+	cmd.Flags().String("type", "", "only delete exports of this type when using --older-than (options: database, media)")
+	_ = cmd.App.BindPFlag("exports_delete_type", cmd.Flags().Lookup("type"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("exports_delete_yes", cmd.Flags().Lookup("yes"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("exports_delete_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
+
+func NewCmdEnvExportsDownload(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "download <id|latest>",
+			Short: "download exported data",
+			Long:  `download exported data by its id or the latest export of the given type`,
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return []string{"latest"}, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvExportsDownload(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "downloading exported data")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("type", "database", "type of the export when downloading the latest one (options: database, media)")
+	_ = cmd.App.BindPFlag("exports_download_type", cmd.Flags().Lookup("type"))
+
+	cmd.Flags().StringP("output", "o", "", "download to this path")
+	_ = cmd.App.BindPFlag("exports_download_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().String("strip-profile", "", "apply the named strip profile of the config file to the downloaded dump")
+	_ = cmd.App.BindPFlag("exports_download_strip_profile", cmd.Flags().Lookup("strip-profile"))
+
+	return cmd
+}
@@ -0,0 +1,80 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvImportDB(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "import-db [file.sql|file.sql.gz]",
+			Short: "import database into the environment",
+			Long: `import a local database dump or an existing export into the environment
+
+The dump is streamed into the database pod. Gzipped dumps are decompressed on the fly.
+Use --from-export to import an export (id or "latest") on the server side.`,
+			Args: cobra.MaximumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return []string{"sql", "gz"}, cobra.ShellCompDirectiveFilterFileExt
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvImportDB(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "importing database")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("from-export", "", "import an existing database export (id or latest)")
+	_ = cmd.App.BindPFlag("import_db_from_export", cmd.Flags().Lookup("from-export"))
+
+	cmd.Flags().BoolP("yes", "y", false, "skip the confirmation for production environments")
+	_ = cmd.App.BindPFlag("import_db_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvImportMedia(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "import-media [dir|archive]",
+			Short: "import media files into the environment",
+			Long: `import a local media directory or a .tar, .tar.gz or .tgz archive into the environment
+
+The files are extracted into the media directory of the application (default depends on the project type).
+Use --from-export to import an export (id or "latest") on the server side.`,
+			Args: cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvImportMedia(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "importing media")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("from-export", "", "import an existing media export (id or latest)")
+	_ = cmd.App.BindPFlag("import_media_from_export", cmd.Flags().Lookup("from-export"))
+
+	cmd.Flags().String("path", "", "target path relative to the application root (default depends on the project type)")
+	_ = cmd.App.BindPFlag("import_media_path", cmd.Flags().Lookup("path"))
+
+	cmd.Flags().BoolP("yes", "y", false, "skip the confirmation for production environments")
+	_ = cmd.App.BindPFlag("import_media_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
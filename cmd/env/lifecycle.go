@@ -0,0 +1,140 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvCreate(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "create",
+			Short: "create a new environment",
+			Long: `create a new environment in the project and wait until it is running
+
+If --name is not set, the name is derived from --branch. The branch is passed to the build using the
+environment variable configured by branch_env_var (default: GIT_BRANCH).`,
+			Args: cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvCreate(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "creating environment")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("name", "", "name of the environment (default: derived from --branch)")
+	_ = cmd.App.BindPFlag("env_create_name", cmd.Flags().Lookup("name"))
+
+	cmd.Flags().String("branch", "", "git branch to deploy")
+	_ = cmd.App.BindPFlag("env_create_branch", cmd.Flags().Lookup("branch"))
+
+	cmd.Flags().String("cluster", "", "cluster id, codename or name (default: the default cluster)")
+	_ = cmd.App.BindPFlag("env_create_cluster", cmd.Flags().Lookup("cluster"))
+
+	cmd.Flags().String("project", "", "project id, codename or name (default: the project of the current context)")
+	_ = cmd.App.BindPFlag("env_create_project", cmd.Flags().Lookup("project"))
+
+	return cmd
+}
+
+func NewCmdEnvDelete(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "delete [environment]",
+			Short:   "delete an environment",
+			Long:    `delete an environment (id, codename or name; default: the environment of the current context)`,
+			Aliases: []string{"rm"},
+			Args:    cobra.MaximumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvDelete(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "deleting environment")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "skip typing the name of the environment to confirm the deletion")
+	_ = cmd.App.BindPFlag("env_delete_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvStart(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "start [environment]",
+			Short: "start a stopped environment",
+			Long:  `start a stopped environment (id, codename or name; default: the environment of the current context)`,
+			Args:  cobra.MaximumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvStart(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "starting environment")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	return cmd
+}
+
+func NewCmdEnvStop(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "stop [environment]",
+			Short: "stop an idle environment",
+			Long:  `stop an idle environment (id, codename or name; default: the environment of the current context)`,
+			Args:  cobra.MaximumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvStop(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "stopping environment")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().BoolP("yes", "y", false, "skip the confirmation for production environments")
+	_ = cmd.App.BindPFlag("env_stop_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
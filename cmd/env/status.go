@@ -0,0 +1,85 @@
+package env
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvList(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "list",
+			Short:   "list environments",
+			Long:    `list the environments of the project`,
+			Aliases: []string{"ls"},
+			Args:    cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvList(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "listing environments")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("project", "", "project id, codename or name (default: the project of the current context)")
+	_ = cmd.App.BindPFlag("env_list_project", cmd.Flags().Lookup("project"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("env_list_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
+
+func NewCmdEnvStatus(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "status",
+			Short: "show the state of the environments",
+			Long:  `show the state of the environments of the project`,
+			Args:  cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvStatus(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "getting environment status")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("project", "", "project id, codename or name (default: the project of the current context)")
+	_ = cmd.App.BindPFlag("env_status_project", cmd.Flags().Lookup("project"))
+
+	cmd.Flags().BoolP("watch", "w", false, "watch the state of the environments")
+	_ = cmd.App.BindPFlag("env_status_watch", cmd.Flags().Lookup("watch"))
+
+	cmd.Flags().Duration("interval", 5*time.Second, "refresh interval of --watch")
+	_ = cmd.App.BindPFlag("env_status_interval", cmd.Flags().Lookup("interval"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("env_status_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
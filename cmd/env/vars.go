@@ -0,0 +1,292 @@
+package env
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdEnvVars(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "vars",
+			Short: "manage environment variables",
+			Long: `manage the environment variables of the environment
+
+Secret values (encrypted variables and variables whose key looks like a secret) are masked
+unless --reveal is used. Changes are listed for review and applied in a single update,
+they take effect after the next deployment (use --deploy to deploy right away).`,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return cmd.Help() //nolint:wrapcheck
+			},
+		},
+		App: app,
+	}
+
+	cmd.AddCommands(
+		NewCmdEnvVarsList(app),
+		NewCmdEnvVarsGet(app),
+		NewCmdEnvVarsSet(app),
+		NewCmdEnvVarsUnset(app),
+		NewCmdEnvVarsImport(app),
+		NewCmdEnvVarsExport(app),
+		NewCmdEnvVarsDiff(app),
+	)
+
+	return cmd
+}
+
+func NewCmdEnvVarsList(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "list",
+			Short:   "list environment variables",
+			Long:    `list the environment variables of the environment`,
+			Aliases: []string{"ls"},
+			Args:    cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsList(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "listing environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("env_vars_list_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().Bool("reveal", false, "show secret values in plain text")
+	_ = cmd.App.BindPFlag("env_vars_list_reveal", cmd.Flags().Lookup("reveal"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("env_vars_list_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsGet(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "get KEY",
+			Short: "print the value of an environment variable",
+			Long:  `print the value of an environment variable`,
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsGet(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "getting environment variable")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("reveal", false, "show secret values in plain text")
+	_ = cmd.App.BindPFlag("env_vars_get_reveal", cmd.Flags().Lookup("reveal"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("env_vars_get_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsSet(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "set KEY=VALUE...",
+			Short: "set environment variables",
+			Long:  `set environment variables, existing variables keep their encryption`,
+			Args:  cobra.MinimumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsSet(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "setting environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("secret", false, "store the variables encrypted")
+	_ = cmd.App.BindPFlag("env_vars_set_secret", cmd.Flags().Lookup("secret"))
+
+	cmd.Flags().Bool("deploy", false, "build and deploy the environment after applying the changes")
+	_ = cmd.App.BindPFlag("env_vars_set_deploy", cmd.Flags().Lookup("deploy"))
+
+	cmd.Flags().BoolP("yes", "y", false, "apply the changes without confirmation")
+	_ = cmd.App.BindPFlag("env_vars_set_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsUnset(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "unset KEY...",
+			Short: "remove environment variables",
+			Long:  `remove environment variables`,
+			Args:  cobra.MinimumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsUnset(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "removing environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("deploy", false, "build and deploy the environment after applying the changes")
+	_ = cmd.App.BindPFlag("env_vars_unset_deploy", cmd.Flags().Lookup("deploy"))
+
+	cmd.Flags().BoolP("yes", "y", false, "apply the changes without confirmation")
+	_ = cmd.App.BindPFlag("env_vars_unset_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsImport(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "import <.env file>",
+			Short: "import environment variables from a dotenv file",
+			Long:  `set the environment variables defined in a dotenv file (KEY=VALUE lines)`,
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveDefault
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsImport(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "importing environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("secret", false, "store the variables encrypted")
+	_ = cmd.App.BindPFlag("env_vars_import_secret", cmd.Flags().Lookup("secret"))
+
+	cmd.Flags().Bool("deploy", false, "build and deploy the environment after applying the changes")
+	_ = cmd.App.BindPFlag("env_vars_import_deploy", cmd.Flags().Lookup("deploy"))
+
+	cmd.Flags().BoolP("yes", "y", false, "apply the changes without confirmation")
+	_ = cmd.App.BindPFlag("env_vars_import_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsExport(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "export",
+			Short: "export environment variables",
+			Long:  `print the environment variables of the environment in dotenv, json or yaml format`,
+			Args:  cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsExport(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "exporting environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().StringP("output", "o", "dotenv", "output format (options: dotenv, json, yaml)")
+	_ = cmd.App.BindPFlag("env_vars_export_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().Bool("reveal", false, "show secret values in plain text")
+	_ = cmd.App.BindPFlag("env_vars_export_reveal", cmd.Flags().Lookup("reveal"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("env_vars_export_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
+
+func NewCmdEnvVarsDiff(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "diff <other-env>",
+			Short: "compare the environment variables of two environments",
+			Long:  `compare the environment variables of the environment with another environment (id, codename or name) of the project`,
+			Args:  cobra.ExactArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewEnvClient(app).RunCmdEnvVarsDiff(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "comparing environment variables")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("env_vars_diff_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().Bool("reveal", false, "show secret values in plain text")
+	_ = cmd.App.BindPFlag("env_vars_diff_reveal", cmd.Flags().Lookup("reveal"))
+
+	cmd.Flags().BoolP("yes", "y", false, "do not ask for confirmation")
+	_ = cmd.App.BindPFlag("env_vars_diff_yes", cmd.Flags().Lookup("yes"))
+
+	return cmd
+}
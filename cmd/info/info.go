@@ -1,6 +1,8 @@
 package info
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/internal/logic"
 	"github.com/spf13/cobra"
@@ -14,7 +16,20 @@ func NewCmdInfo(app *config.App) *cmdpkg.Command {
 		Command: &cobra.Command{
 			Use:   "info",
 			Short: "info",
-			Long:  `info`,
+			Long: `info
+
+With --export the credentials of the environment (database host, port, user, password and the urls) are
+printed for local tooling instead, e.g.:
+  eval "$(cloud info --export=shell)"
+  cloud info --export=jetbrains --export-file .idea/dataSources.xml
+
+Files are written readable only by you. The variables are merged into existing dotenv and shell files and the
+data source into existing DBeaver files, other existing files are only replaced with --force. The password is
+only written to JetBrains data sources with --include-password, as they are usually shared in the repository.
+The format of .json files has to be set.
+
+The database port is the local port of the running background port-forward of the database (see port-forward
+db --background) or the port of the database engine.`,
 			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
 				[]string, cobra.ShellCompDirective,
 			) {
@@ -32,5 +47,19 @@ func NewCmdInfo(app *config.App) *cmdpkg.Command {
 		App: app,
 	}
 
+	cmd.Flags().String("export", "",
+		"export the credentials (options: "+strings.Join(logic.ExportFormats, ", ")+")")
+	_ = cmd.App.BindPFlag("info_export", cmd.Flags().Lookup("export"))
+
+	cmd.Flags().String("export-file", "", "write the exported credentials to the file instead of printing them "+
+		"(the format is guessed from the file name if --export is not set)")
+	_ = cmd.App.BindPFlag("info_export_file", cmd.Flags().Lookup("export-file"))
+
+	cmd.Flags().Bool("force", false, "replace the --export-file if the credentials cannot be merged into it")
+	_ = cmd.App.BindPFlag("info_export_force", cmd.Flags().Lookup("force"))
+
+	cmd.Flags().Bool("include-password", false, "include the password in the JetBrains data source")
+	_ = cmd.App.BindPFlag("info_export_include_password", cmd.Flags().Lookup("include-password"))
+
 	return cmd
 }
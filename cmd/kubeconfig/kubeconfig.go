@@ -0,0 +1,55 @@
+package kubeconfig
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdKubeconfig(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "kubeconfig",
+			Short: "export the kubeconfig of the current environment",
+			Long: `export the kubeconfig of the current environment
+
+The kubeconfig contains a context called reward-<project>-<environment> with the namespace of the
+environment preset, so it can be used with kubectl, k9s, Lens or any other kubernetes tool.
+
+Without flags the kubeconfig is printed. With --merge the context is added to the given kubeconfig
+file, or updated if it already exists. --remove deletes the context from the --merge file or from
+the default kubeconfig.
+
+Examples:
+  cloud kubeconfig > env.kubeconfig
+  cloud kubeconfig --merge ~/.kube/config
+  cloud kubeconfig --remove`,
+			Args: cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewKubeconfigClient(app).RunCmdKubeconfig(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "running kubeconfig command")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("merge", "", "kubeconfig file to add the context to")
+	_ = cmd.App.BindPFlag("kubeconfig_merge", cmd.Flags().Lookup("merge"))
+
+	cmd.Flags().Bool("print", false, "print the kubeconfig also when merging")
+	_ = cmd.App.BindPFlag("kubeconfig_print", cmd.Flags().Lookup("print"))
+
+	cmd.Flags().Bool("remove", false, "remove the context of the environment from the kubeconfig file")
+	_ = cmd.App.BindPFlag("kubeconfig_remove", cmd.Flags().Lookup("remove"))
+
+	cmd.MarkFlagsMutuallyExclusive("print", "remove")
+
+	return cmd
+}
@@ -0,0 +1,73 @@
+package media
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdMedia(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "media",
+			Short: "interact with the media files of the environment",
+			Long:  `interact with the media files of the environment`,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return cmd.Help() //nolint:wrapcheck
+			},
+		},
+		App: app,
+	}
+
+	cmd.AddCommands(
+		NewCmdMediaPull(app),
+	)
+
+	return cmd
+}
+
+func NewCmdMediaPull(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "pull",
+			Short: "sync the media files of the environment to the local environment",
+			Long: `export the media files of the environment, download them and extract them into the local environment
+
+The command has to be run from the root of the local environment.`,
+			Args: cobra.NoArgs,
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewMediaClient(app).RunCmdMediaPull(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "pulling media")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().String("from-export", "", "use an existing export (id or latest) instead of creating a new one")
+	_ = cmd.App.BindPFlag("media_pull_from_export", cmd.Flags().Lookup("from-export"))
+
+	cmd.Flags().String("path", "", "local media directory (default depends on the project type)")
+	_ = cmd.App.BindPFlag("media_pull_path", cmd.Flags().Lookup("path"))
+
+	cmd.Flags().Bool("keep", false, "keep the downloaded export")
+	_ = cmd.App.BindPFlag("media_pull_keep", cmd.Flags().Lookup("keep"))
+
+	return cmd
+}
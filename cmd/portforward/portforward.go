@@ -1,6 +1,8 @@
 package portforward
 
 import (
+	"strings"
+
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/internal/logic"
 	"github.com/spf13/cobra"
@@ -12,23 +14,64 @@ import (
 func NewCmdPortForward(app *config.App) *cmdpkg.Command {
 	cmd := &cmdpkg.Command{
 		Command: &cobra.Command{
-			Use:   "port-forward",
+			Use:   "port-forward [service[:local port]...]",
 			Short: "port-forward services",
-			Long:  `port-forward services`,
+			Long: `forward local ports to the services of the environment, e.g.:
+  port-forward redis
+  port-forward opensearch --local-port 19200
+  port-forward db redis:16379 opensearch
+  port-forward --list
+
+The built-in services are db, redis, opensearch, elasticsearch, rabbitmq, rabbitmq-management,
+varnish, mailhog and web. Services can be added or overridden in the config file:
+
+  port_forward_services:
+    solr:
+      component: solr
+      port: 8983
+
+By default the local port is the same as the remote port of the service. Use auto as the local port
+(e.g. db:auto or --local-port=auto) to pick a free port if the remote port is already taken. The services are forwarded
+in one session until you quit. Lost connections are reconnected with backoff and the pods are looked
+up again, so the tunnels survive pod restarts and rollouts.
+
+With --background the port-forward runs in a detached process. Use port-forward list to show the
+running port-forwards and port-forward stop to stop them.`,
+			Args: cobra.ArbitraryArgs,
 			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
 				[]string, cobra.ShellCompDirective,
 			) {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			},
 			RunE: func(cmd *cobra.Command, args []string) error {
-				return cmd.Help() //nolint:wrapcheck
+				err := logic.NewPortForwardClient(app).RunCmdPortForward(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "running port-forward command")
+				}
+
+				return nil
 			},
 		},
 		App: app,
 	}
 
+	cmd.Flags().String("local-port", "", "local port or auto to use a free port if the remote port is taken "+
+		"(default: the remote port of the service)")
+	_ = cmd.App.BindPFlag("port_forward_local_port", cmd.Flags().Lookup("local-port"))
+
+	cmd.Flags().Bool("list", false, "list the services which can be forwarded")
+	_ = cmd.App.BindPFlag("port_forward_list", cmd.Flags().Lookup("list"))
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("port_forward_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().Bool("background", false, "run the port-forward in the background")
+	_ = cmd.App.BindPFlag("port_forward_background", cmd.Flags().Lookup("background"))
+
 	cmd.AddCommands(
 		NewCmdPortForwardDB(app),
+		NewCmdPortForwardList(app),
+		NewCmdPortForwardStop(app),
 	)
 
 	return cmd
@@ -37,9 +80,24 @@ func NewCmdPortForward(app *config.App) *cmdpkg.Command {
 func NewCmdPortForwardDB(app *config.App) *cmdpkg.Command {
 	cmd := &cmdpkg.Command{
 		Command: &cobra.Command{
-			Use:   "db",
+			Use:   "db [service[:local port]...]",
 			Short: "port-forward database port",
-			Long:  `port-forward database port`,
+			Long: `port-forward database port and print the credentials of the database
+
+The database engine (MySQL, MariaDB or PostgreSQL) and its port are derived from the project type version.
+
+Further services can be forwarded in the same session, e.g.:
+  port-forward db redis opensearch
+
+With --write-config the credentials are written to a file for local tooling: a dotenv file, a shell script
+(.sh), a MySQL option file (.cnf), a JetBrains data source (.xml) or a DBeaver data source (set
+--write-config-format=dbeaver), e.g.:
+  port-forward db --write-config .env
+  port-forward db --write-config .idea/dataSources.xml
+
+The variables are merged into existing dotenv and shell files and the data source into existing DBeaver files,
+other existing files are only replaced with --force. The password is only written to JetBrains data sources
+with --include-password, as they are usually shared in the repository.`,
 			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
 				[]string, cobra.ShellCompDirective,
 			) {
@@ -57,12 +115,82 @@ func NewCmdPortForwardDB(app *config.App) *cmdpkg.Command {
 		App: app,
 	}
 
-	cmd.Flags().Int(
+	cmd.Flags().String(
 		"local-port",
-		3306,
 		"",
+		"local port or auto to use a free port if it is taken (default: the port of the database engine)",
 	)
 	_ = cmd.App.BindPFlag("local_port", cmd.Flags().Lookup("local-port"))
 
+	cmd.Flags().StringP("output", "o", "table", "output format of the credentials (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("port_forward_db_output", cmd.Flags().Lookup("output"))
+
+	cmd.Flags().Bool("background", false, "run the port-forward in the background")
+	_ = cmd.App.BindPFlag("port_forward_db_background", cmd.Flags().Lookup("background"))
+
+	cmd.Flags().String("write-config", "", "write the credentials to the file (readable only by you)")
+	_ = cmd.App.BindPFlag("port_forward_db_write_config", cmd.Flags().Lookup("write-config"))
+
+	cmd.Flags().String("write-config-format", "", "format of the --write-config file, guessed from the file name "+
+		"if not set (options: "+strings.Join(logic.ExportFormats, ", ")+")")
+	_ = cmd.App.BindPFlag("port_forward_db_write_config_format", cmd.Flags().Lookup("write-config-format"))
+
+	cmd.Flags().Bool("force", false, "replace the --write-config file if the credentials cannot be merged into it")
+	_ = cmd.App.BindPFlag("port_forward_db_write_config_force", cmd.Flags().Lookup("force"))
+
+	cmd.Flags().Bool("include-password", false, "include the password in the JetBrains data source")
+	_ = cmd.App.BindPFlag("port_forward_db_write_config_include_password", cmd.Flags().Lookup("include-password"))
+
+	return cmd
+}
+
+func NewCmdPortForwardList(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:     "list",
+			Aliases: []string{"ls"},
+			Short:   "list the port-forwards running in the background",
+			Long:    `list the port-forwards running in the background in all contexts`,
+			Args:    cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewPortForwardClient(app).RunCmdPortForwardList(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "listing port-forwards")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().StringP("output", "o", "table", "output format (options: table, json, yaml)")
+	_ = cmd.App.BindPFlag("port_forward_list_output", cmd.Flags().Lookup("output"))
+
+	return cmd
+}
+
+func NewCmdPortForwardStop(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "stop <id>",
+			Short: "stop port-forwards running in the background",
+			Long:  `stop a port-forward running in the background or all of them with --all`,
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				err := logic.NewPortForwardClient(app).RunCmdPortForwardStop(cmd, args)
+				if err != nil {
+					return errors.Wrap(err, "stopping port-forward")
+				}
+
+				return nil
+			},
+		},
+		App: app,
+	}
+
+	cmd.Flags().Bool("all", false, "stop all port-forwards")
+	_ = cmd.App.BindPFlag("port_forward_stop_all", cmd.Flags().Lookup("all"))
+
 	return cmd
 }
@@ -7,8 +7,13 @@ import (
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/cmd/cache"
 	"github.com/rewardenv/reward-cloud-cli/cmd/context"
+	"github.com/rewardenv/reward-cloud-cli/cmd/cp"
+	"github.com/rewardenv/reward-cloud-cli/cmd/credential"
+	"github.com/rewardenv/reward-cloud-cli/cmd/db"
 	"github.com/rewardenv/reward-cloud-cli/cmd/env"
 	"github.com/rewardenv/reward-cloud-cli/cmd/info"
+	"github.com/rewardenv/reward-cloud-cli/cmd/kubeconfig"
+	"github.com/rewardenv/reward-cloud-cli/cmd/media"
 	"github.com/rewardenv/reward-cloud-cli/cmd/portforward"
 
 	"github.com/rewardenv/reward/pkg/util"
@@ -66,10 +71,18 @@ func NewCmdRoot(conf *config.App) *cmdpkg.Command {
 		cache.NewCmdCache(conf),
 		context.NewCmdContext(conf),
 		login.NewCmdLogin(conf),
+		credential.NewCmdCredential(conf),
 		shell.NewCmdShell(conf),
+		shell.NewCmdExec(conf),
+		cp.NewCmdCp(conf),
+		kubeconfig.NewCmdKubeconfig(conf),
+		shell.NewCmdKubectl(conf),
+		shell.NewCmdK9s(conf),
 		portforward.NewCmdPortForward(conf),
 		env.NewCmdEnv(conf),
 		info.NewCmdInfo(conf),
+		db.NewCmdDB(conf),
+		media.NewCmdMedia(conf),
 	)
 
 	return cmd
@@ -0,0 +1,47 @@
+package shell
+
+import (
+	"github.com/spf13/cobra"
+
+	cmdpkg "github.com/rewardenv/reward-cloud-cli/cmd"
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/logic"
+)
+
+func NewCmdKubectl(app *config.App) *cmdpkg.Command {
+	return &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "kubectl [args...]",
+			Short: "run kubectl against the current environment",
+			Long: `run kubectl against the current environment, e.g.:
+  kubectl get pods
+  kubectl logs -f deploy/main
+
+The kubeconfig, cache directory and namespace of the current context are passed to kubectl. All
+arguments are passed to kubectl as they are and the CLI exits with the exit code of kubectl.`,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runShell(cmd, args, logic.NewPassthroughClient(app).RunCmdKubectl)
+			},
+		},
+		App: app,
+	}
+}
+
+func NewCmdK9s(app *config.App) *cmdpkg.Command {
+	return &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "k9s [args...]",
+			Short: "run k9s against the current environment",
+			Long: `run k9s against the current environment
+
+The kubeconfig and namespace of the current context are passed to k9s. All arguments are passed to
+k9s as they are.`,
+			DisableFlagParsing: true,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runShell(cmd, args, logic.NewPassthroughClient(app).RunCmdK9s)
+			},
+		},
+		App: app,
+	}
+}
@@ -13,25 +13,99 @@ import (
 func NewCmdShell(app *config.App) *cmdpkg.Command {
 	cmd := &cmdpkg.Command{
 		Command: &cobra.Command{
-			Use:   "shell",
+			Use:   "shell [-- command [args...]]",
 			Short: "open a shell in a reward cloud environment",
-			Long:  `open a shell in a reward cloud environment`,
+			Long: `open a shell in a reward cloud environment
+
+If a command is given after --, it is run instead of the shell, e.g.:
+  shell -- bin/magento cache:flush
+
+A TTY is only allocated if the standard input is a terminal. The standard output and error of the
+command are kept separate and the CLI exits with the exit code of the command.
+
+By default the main container of the main component is used. If a component has several pods or
+containers and neither --pod nor --container is set, they can be selected interactively.`,
 			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
 				[]string, cobra.ShellCompDirective,
 			) {
 				return nil, cobra.ShellCompDirectiveNoFileComp
 			},
 			RunE: func(cmd *cobra.Command, args []string) error {
-				err := logic.NewShellClient(app).RunCmdShell(cmd, args)
-				if err != nil {
-					return errors.Wrap(err, "running shell command")
-				}
+				return runShell(cmd, args, logic.NewShellClient(app).RunCmdShell)
+			},
+		},
+		App: app,
+	}
+
+	addShellFlags(cmd, "shell")
+
+	return cmd
+}
+
+func NewCmdExec(app *config.App) *cmdpkg.Command {
+	cmd := &cmdpkg.Command{
+		Command: &cobra.Command{
+			Use:   "exec command [args...]",
+			Short: "run a command in a reward cloud environment",
+			Long: `run a command in a reward cloud environment, e.g.:
+  exec bin/magento cache:flush
+  exec --component db mysql -e 'SHOW PROCESSLIST'
+  exec --user www-data --workdir /var/www/html bin/magento indexer:status
 
-				return nil
+A TTY is only allocated if the standard input is a terminal. The standard output and error of the
+command are kept separate and the CLI exits with the exit code of the command.`,
+			Args: cobra.MinimumNArgs(1),
+			ValidArgsFunction: func(cmd *cobra.Command, args []string, toComplete string) (
+				[]string, cobra.ShellCompDirective,
+			) {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			},
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return runShell(cmd, args, logic.NewShellClient(app).RunCmdExec)
 			},
 		},
 		App: app,
 	}
 
+	addShellFlags(cmd, "exec")
+
+	// Flags after the command belong to the remote command.
+	cmd.Flags().SetInterspersed(false)
+
 	return cmd
 }
+
+func addShellFlags(cmd *cmdpkg.Command, keyPrefix string) {
+	cmd.Flags().String("component", logic.ComponentMain, "component to use (e.g. main, db, redis, varnish)")
+	_ = cmd.App.BindPFlag(keyPrefix+"_component", cmd.Flags().Lookup("component"))
+
+	cmd.Flags().String("container", "", "container to use (default: the main container of the component)")
+	_ = cmd.App.BindPFlag(keyPrefix+"_container", cmd.Flags().Lookup("container"))
+
+	cmd.Flags().String("pod", "", "pod to use, overrides --component")
+	_ = cmd.App.BindPFlag(keyPrefix+"_pod", cmd.Flags().Lookup("pod"))
+
+	cmd.Flags().String("user", "", "run as this user (e.g. www-data), requires su in the container")
+	_ = cmd.App.BindPFlag(keyPrefix+"_user", cmd.Flags().Lookup("user"))
+
+	cmd.Flags().String("workdir", "", "working directory of the command")
+	_ = cmd.App.BindPFlag(keyPrefix+"_workdir", cmd.Flags().Lookup("workdir"))
+}
+
+func runShell(cmd *cobra.Command, args []string, run func(cmd *cobra.Command, args []string) error) error {
+	err := run(cmd, args)
+
+	// The remote command already printed its errors, only its exit code is passed through.
+	var exitErr *logic.ExitCodeError
+	if errors.As(err, &exitErr) {
+		cmd.SilenceErrors = true
+
+		return exitErr
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "running shell command")
+	}
+
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward/pkg/util"
@@ -60,6 +61,33 @@ func (a *App) Cleanup() error {
 	return err
 }
 
+// staleTmpFileAge is the minimum age of the temporary files removed by SweepTmpFiles.
+const staleTmpFileAge = time.Hour
+
+// SweepTmpFiles removes the temporary kubeconfig files which were left in the cache directory by earlier versions
+// when the process crashed or exited before Cleanup.
+func (a *App) SweepTmpFiles() {
+	files, err := filepath.Glob(filepath.Join(a.CacheDir(), fmt.Sprintf("%s-*", a.AppName())))
+	if err != nil {
+		return
+	}
+
+	for _, f := range files {
+		fi, err := os.Lstat(f)
+		if err != nil || !fi.Mode().IsRegular() || time.Since(fi.ModTime()) < staleTmpFileAge {
+			continue
+		}
+
+		if err := os.Remove(f); err != nil {
+			log.Debugf("Cannot remove stale temporary file %s: %s", f, err)
+
+			continue
+		}
+
+		log.Debugf("Removed stale temporary file %s", f)
+	}
+}
+
 func (a *App) Init() *App {
 	// Configure defaults.
 	a.SetDefault("silence_errors", true)
@@ -94,6 +122,12 @@ func (a *App) Init() *App {
 	// Cloud API App
 	a.SetDefault(fmt.Sprintf("%s_endpoint", a.ConfigPrefix()), "rewardcloud.itg.cloud")
 
+	// Environments matching this pattern require a typed confirmation before destructive actions
+	a.SetDefault("production_environment_pattern", `(?i)(^|[^a-z])(prod|production|live)([^a-z]|$)`)
+
+	// The git branch of new environments is passed to the build using this environment variable
+	a.SetDefault("branch_env_var", "GIT_BRANCH")
+
 	a.AddConfigPath(".")
 
 	cfg := a.ConfigFilePath()
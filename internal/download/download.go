@@ -0,0 +1,409 @@
+package download
+
+import (
+	"context"
+	"crypto/md5" //nolint:gosec
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// PartSuffix is appended to the destination while the download is in progress.
+const PartSuffix = ".part"
+
+// ETagSuffix is appended to the partial file to store the ETag of the object it belongs to.
+const ETagSuffix = ".etag"
+
+const progressInterval = 100 * time.Millisecond
+
+// ErrChecksumMismatch is returned when the downloaded file does not match the expected checksum.
+var ErrChecksumMismatch = errors.New("checksum mismatch")
+
+type options struct {
+	Client   *http.Client
+	Checksum string
+	Progress func(written, total int64)
+}
+
+type Option func(*options)
+
+// WithHTTPClient sets the http client used for the download.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.Client = client
+	}
+}
+
+// WithChecksum sets the expected checksum of the file in the "<algorithm>:<hex digest>" format.
+// Supported algorithms are md5 and sha256.
+func WithChecksum(checksum string) Option {
+	return func(o *options) {
+		o.Checksum = checksum
+	}
+}
+
+// WithProgress sets a callback which is called periodically with the number of bytes written and the total size
+// of the file. Total is -1 if the size is unknown.
+func WithProgress(fn func(written, total int64)) Option {
+	return func(o *options) {
+		o.Progress = fn
+	}
+}
+
+// File downloads url to dest. The content is written to dest + PartSuffix first and moved to its place only after
+// the download is complete and verified. If a partial file is found from an interrupted download, the download is
+// resumed using an HTTP range request. The range request is conditional on the ETag of the partial file, so the
+// download starts over if the object has changed since.
+//
+//nolint:cyclop
+func File(ctx context.Context, url, dest string, opts ...Option) error {
+	o := &options{
+		Client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	part := dest + PartSuffix
+
+	var (
+		offset int64
+		etag   = readETag(part)
+	)
+
+	if fi, err := os.Stat(part); err == nil && etag != "" {
+		offset = fi.Size()
+	}
+
+	resp, err := get(ctx, o.Client, url, offset, etag)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var (
+		flags = os.O_CREATE | os.O_WRONLY
+		total int64
+	)
+
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		start, size, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err != nil {
+			return err
+		}
+
+		if start != offset {
+			return errors.Errorf("server resumed the download at byte %d instead of %d", start, offset)
+		}
+
+		if resp.Header.Get("ETag") != etag {
+			return errors.Errorf("server resumed the download of a different version of %s", url)
+		}
+
+		log.Debugf("Resuming download of %s at byte %d", dest, offset)
+
+		flags |= os.O_APPEND
+		total = size
+	case http.StatusOK:
+		offset = 0
+		flags |= os.O_TRUNC
+		total = resp.ContentLength
+	case http.StatusRequestedRangeNotSatisfiable:
+		// The partial file is either complete or it does not belong to this url anymore.
+		_, size, _ := parseContentRange(resp.Header.Get("Content-Range"))
+		if size >= 0 && size == offset {
+			return finish(part, dest, o.Checksum, resp.Header)
+		}
+
+		if err := removePart(part); err != nil {
+			return err
+		}
+
+		return File(ctx, url, dest, opts...)
+	default:
+		return errors.Errorf("downloading %s: unexpected status: %s", url, resp.Status)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dest), 0o755); err != nil {
+		return errors.Wrap(err, "creating destination directory")
+	}
+
+	if resp.StatusCode == http.StatusOK {
+		if err := writeETag(part, resp.Header.Get("ETag")); err != nil {
+			return err
+		}
+	}
+
+	f, err := os.OpenFile(part, flags, 0o644)
+	if err != nil {
+		return errors.Wrap(err, "opening partial download")
+	}
+
+	pw := &progressWriter{
+		written:  offset,
+		total:    total,
+		callback: o.Progress,
+	}
+
+	_, err = io.Copy(io.MultiWriter(f, pw), resp.Body)
+	pw.report(true)
+
+	if err != nil {
+		_ = f.Close()
+
+		return errors.Wrap(err, "downloading file, run the command again to resume")
+	}
+
+	if err := f.Sync(); err != nil {
+		_ = f.Close()
+
+		return errors.Wrap(err, "syncing partial download")
+	}
+
+	if err := f.Close(); err != nil {
+		return errors.Wrap(err, "closing partial download")
+	}
+
+	if total >= 0 && pw.written != total {
+		return errors.Errorf("size mismatch: expected %d bytes, got %d, run the command again to resume",
+			total, pw.written)
+	}
+
+	return finish(part, dest, o.Checksum, resp.Header)
+}
+
+func get(ctx context.Context, client *http.Client, url string, offset int64, etag string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating request")
+	}
+
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", etag)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "sending request")
+	}
+
+	return resp, nil
+}
+
+// finish verifies the partial file and atomically moves it to its final place.
+func finish(part, dest, checksum string, header http.Header) error {
+	if checksum == "" {
+		checksum = checksumFromHeader(header)
+	}
+
+	if checksum != "" {
+		if err := verify(part, checksum); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(part, dest); err != nil {
+		return errors.Wrap(err, "moving download to its destination")
+	}
+
+	if err := os.Remove(part + ETagSuffix); err != nil && !os.IsNotExist(err) {
+		return errors.Wrap(err, "removing etag of partial download")
+	}
+
+	return nil
+}
+
+// readETag returns the stored ETag of the partial file. Weak ETags are not returned because they cannot be used
+// in range requests.
+func readETag(part string) string {
+	b, err := os.ReadFile(part + ETagSuffix)
+	if err != nil {
+		return ""
+	}
+
+	etag := strings.TrimSpace(string(b))
+	if strings.HasPrefix(etag, "W/") {
+		return ""
+	}
+
+	return etag
+}
+
+// writeETag stores the ETag of the object next to the partial file, or removes the stale one if the server did not
+// send an ETag.
+func writeETag(part, etag string) error {
+	if etag == "" {
+		if err := os.Remove(part + ETagSuffix); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing etag of partial download")
+		}
+
+		return nil
+	}
+
+	return errors.Wrap(os.WriteFile(part+ETagSuffix, []byte(etag), 0o644), "writing etag of partial download")
+}
+
+// removePart removes the partial file and its ETag.
+func removePart(part string) error {
+	for _, f := range []string{part, part + ETagSuffix} {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing stale partial download")
+		}
+	}
+
+	return nil
+}
+
+// checksumFromHeader returns the checksum of the object if the server sent one.
+func checksumFromHeader(header http.Header) string {
+	if v := header.Get("X-Amz-Checksum-Sha256"); v != "" {
+		if bs, err := base64.StdEncoding.DecodeString(v); err == nil {
+			return "sha256:" + hex.EncodeToString(bs)
+		}
+	}
+
+	if v := header.Get("Content-MD5"); v != "" {
+		if bs, err := base64.StdEncoding.DecodeString(v); err == nil {
+			return "md5:" + hex.EncodeToString(bs)
+		}
+	}
+
+	return ""
+}
+
+func verify(file, checksum string) error {
+	algo, expected, found := strings.Cut(checksum, ":")
+	if !found {
+		return errors.Errorf("invalid checksum format: %s", checksum)
+	}
+
+	var h hash.Hash
+
+	switch strings.ToLower(algo) {
+	case "md5":
+		h = md5.New() //nolint:gosec
+	case "sha256":
+		h = sha256.New()
+	default:
+		return errors.Errorf("unsupported checksum algorithm: %s", algo)
+	}
+
+	f, err := os.Open(file)
+	if err != nil {
+		return errors.Wrap(err, "opening file for verification")
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(h, f); err != nil {
+		return errors.Wrap(err, "calculating checksum")
+	}
+
+	if actual := hex.EncodeToString(h.Sum(nil)); !strings.EqualFold(actual, expected) {
+		// A corrupted partial file cannot be resumed.
+		_ = os.Remove(file)
+
+		return errors.Wrapf(ErrChecksumMismatch, "expected %s, got %s:%s", checksum, algo, actual)
+	}
+
+	return nil
+}
+
+// parseContentRange parses the start offset and the complete size from a "bytes <start>-<end>/<size>"
+// or a "bytes */<size>" header. The size is -1 if it is unknown.
+func parseContentRange(s string) (start, size int64, err error) {
+	s = strings.TrimPrefix(strings.TrimSpace(s), "bytes ")
+
+	rng, sizeStr, found := strings.Cut(s, "/")
+	if !found {
+		return 0, -1, errors.Errorf("invalid content range: %q", s)
+	}
+
+	size = -1
+	if sizeStr != "*" {
+		size, err = strconv.ParseInt(sizeStr, 10, 64)
+		if err != nil {
+			return 0, -1, errors.Wrapf(err, "invalid content range: %q", s)
+		}
+	}
+
+	if rng == "*" {
+		return 0, size, nil
+	}
+
+	startStr, _, _ := strings.Cut(rng, "-")
+
+	start, err = strconv.ParseInt(startStr, 10, 64)
+	if err != nil {
+		return 0, -1, errors.Wrapf(err, "invalid content range: %q", s)
+	}
+
+	return start, size, nil
+}
+
+type progressWriter struct {
+	written  int64
+	total    int64
+	last     time.Time
+	callback func(written, total int64)
+}
+
+func (w *progressWriter) Write(p []byte) (int, error) {
+	w.written += int64(len(p))
+	w.report(false)
+
+	return len(p), nil
+}
+
+func (w *progressWriter) report(force bool) {
+	if w.callback == nil {
+		return
+	}
+
+	if !force && time.Since(w.last) < progressInterval {
+		return
+	}
+
+	w.last = time.Now()
+	w.callback(w.written, w.total)
+}
+
+// Size returns the size of the remote file using a HEAD request. It returns -1 if the size is unknown.
+func Size(ctx context.Context, url string, opts ...Option) (int64, error) {
+	o := &options{
+		Client: http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return -1, errors.Wrap(err, "creating request")
+	}
+
+	resp, err := o.Client.Do(req)
+	if err != nil {
+		return -1, errors.Wrap(err, "sending request")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return -1, errors.Errorf("getting size of %s: unexpected status: %s", url, resp.Status)
+	}
+
+	return resp.ContentLength, nil
+}
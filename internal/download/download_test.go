@@ -0,0 +1,152 @@
+package download
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DownloadTestSuite struct {
+	suite.Suite
+	content []byte
+	etag    string
+	server  *httptest.Server
+}
+
+func (suite *DownloadTestSuite) SetupTest() {
+	suite.content = bytes.Repeat([]byte("reward-cloud"), 10000)
+	suite.etag = `"v2"`
+	suite.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("ETag", suite.etag)
+		http.ServeContent(w, r, "dump.sql.gz", time.Time{}, bytes.NewReader(suite.content))
+	}))
+}
+
+func (suite *DownloadTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func TestDownloadTestSuite(t *testing.T) {
+	suite.Run(t, new(DownloadTestSuite))
+}
+
+func (suite *DownloadTestSuite) TestFile() {
+	sum := sha256.Sum256(suite.content)
+
+	tests := []struct {
+		name     string
+		partial  []byte
+		etag     string
+		checksum string
+		wantErr  bool
+	}{
+		{
+			name: "full download",
+		},
+		{
+			name:    "resume partial download",
+			partial: suite.content[:1234],
+			etag:    `"v2"`,
+		},
+		{
+			name:    "complete partial download",
+			partial: suite.content,
+			etag:    `"v2"`,
+		},
+		{
+			name:    "partial download of another version",
+			partial: bytes.Repeat([]byte("x"), 1234),
+			etag:    `"v1"`,
+		},
+		{
+			name:    "partial download without etag",
+			partial: bytes.Repeat([]byte("x"), 1234),
+		},
+		{
+			name:     "valid checksum",
+			checksum: "sha256:" + hex.EncodeToString(sum[:]),
+		},
+		{
+			name:     "invalid checksum",
+			checksum: "sha256:" + hex.EncodeToString(make([]byte, sha256.Size)),
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			dest := filepath.Join(suite.T().TempDir(), "dump.sql.gz")
+
+			if tt.partial != nil {
+				suite.Require().NoError(os.WriteFile(dest+PartSuffix, tt.partial, 0o600))
+			}
+
+			if tt.etag != "" {
+				suite.Require().NoError(os.WriteFile(dest+PartSuffix+ETagSuffix, []byte(tt.etag), 0o600))
+			}
+
+			var written int64
+
+			err := File(context.Background(), suite.server.URL, dest,
+				WithChecksum(tt.checksum),
+				WithProgress(func(w, _ int64) {
+					written = w
+				}),
+			)
+			if tt.wantErr {
+				suite.Error(err)
+				suite.NoFileExists(dest)
+
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.NoFileExists(dest + PartSuffix)
+			suite.NoFileExists(dest + PartSuffix + ETagSuffix)
+
+			got, err := os.ReadFile(dest)
+			suite.Require().NoError(err)
+			suite.Equal(suite.content, got)
+
+			if tt.partial == nil || len(tt.partial) < len(suite.content) {
+				suite.Equal(int64(len(suite.content)), written)
+			}
+		})
+	}
+}
+
+func (suite *DownloadTestSuite) TestParseContentRange() {
+	tests := []struct {
+		in        string
+		wantStart int64
+		wantSize  int64
+		wantErr   bool
+	}{
+		{in: "bytes 100-199/200", wantStart: 100, wantSize: 200},
+		{in: "bytes 0-99/*", wantStart: 0, wantSize: -1},
+		{in: "bytes */200", wantStart: 0, wantSize: 200},
+		{in: "invalid", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		start, size, err := parseContentRange(tt.in)
+		if tt.wantErr {
+			suite.Error(err, tt.in)
+
+			continue
+		}
+
+		suite.NoError(err, tt.in)
+		suite.Equal(tt.wantStart, start, tt.in)
+		suite.Equal(tt.wantSize, size, tt.in)
+	}
+}
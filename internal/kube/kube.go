@@ -0,0 +1,259 @@
+// Package kube is a thin layer over client-go to list pods of an environment, run commands in their containers
+// and forward ports to them without depending on kubectl.
+package kube
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/portforward"
+	"k8s.io/client-go/tools/remotecommand"
+	"k8s.io/client-go/transport/spdy"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+const defaultContainerAnnotation = "kubectl.kubernetes.io/default-container"
+
+// terminalSizePollInterval is the interval of checking the size of the local terminal during TTY sessions.
+const terminalSizePollInterval = 250 * time.Millisecond
+
+func init() { //nolint:gochecknoinits
+	// client-go reports the errors of the port-forward streams using klog, which would mix with the output of the
+	// CLI and break the status tables.
+	utilruntime.ErrorHandlers = []func(error){
+		func(err error) {
+			log.Debugf("Kubernetes client error: %s", err)
+		},
+	}
+}
+
+// Client runs operations in a namespace of a cluster.
+type Client struct {
+	Clientset kubernetes.Interface
+	Config    *rest.Config
+	Namespace string
+}
+
+// NewClient returns a client using the kubeconfig file.
+func NewClient(kubeconfig, namespace string) (*Client, error) {
+	config, err := clientcmd.BuildConfigFromFlags("", kubeconfig)
+	if err != nil {
+		return nil, errors.Wrap(err, "loading kubeconfig")
+	}
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating clientset")
+	}
+
+	return &Client{
+		Clientset: clientset,
+		Config:    config,
+		Namespace: namespace,
+	}, nil
+}
+
+// ListPods returns the pods matching the label selector. An empty selector returns all pods of the namespace.
+func (c *Client) ListPods(ctx context.Context, selector string) ([]corev1.Pod, error) {
+	pods, err := c.Clientset.CoreV1().Pods(c.Namespace).List(ctx, metav1.ListOptions{LabelSelector: selector})
+	if err != nil {
+		return nil, errors.Wrap(err, "listing pods")
+	}
+
+	return pods.Items, nil
+}
+
+// GetPod returns the pod with the given name.
+func (c *Client) GetPod(ctx context.Context, name string) (*corev1.Pod, error) {
+	pod, err := c.Clientset.CoreV1().Pods(c.Namespace).Get(ctx, name, metav1.GetOptions{})
+
+	return pod, errors.Wrapf(err, "getting pod %s", name)
+}
+
+// ExecOptions configures a command run in a container.
+type ExecOptions struct {
+	Container string
+	Command   []string
+	Stdin     io.Reader
+	Stdout    io.Writer
+	Stderr    io.Writer
+	// TTY allocates a terminal. If Stdin is a local terminal, it is put into raw mode and its size is propagated.
+	TTY bool
+}
+
+// Exec runs the command in the container of the pod. Without a container the default container of the pod is
+// used. If the command exits with a non-zero code, the returned error can be inspected using ExitCode.
+func (c *Client) Exec(ctx context.Context, pod string, opts ExecOptions) error {
+	if opts.Container == "" {
+		p, err := c.GetPod(ctx, pod)
+		if err != nil {
+			return err
+		}
+
+		opts.Container = DefaultContainer(p)
+	}
+
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(c.Namespace).
+		Name(pod).
+		SubResource("exec").
+		VersionedParams(&corev1.PodExecOptions{
+			Container: opts.Container,
+			Command:   opts.Command,
+			Stdin:     opts.Stdin != nil,
+			Stdout:    opts.Stdout != nil,
+			Stderr:    opts.Stderr != nil && !opts.TTY,
+			TTY:       opts.TTY,
+		}, scheme.ParameterCodec)
+
+	executor, err := remotecommand.NewSPDYExecutor(c.Config, http.MethodPost, req.URL())
+	if err != nil {
+		return errors.Wrap(err, "creating executor")
+	}
+
+	streamOpts := remotecommand.StreamOptions{
+		Stdin:  opts.Stdin,
+		Stdout: opts.Stdout,
+		Tty:    opts.TTY,
+	}
+
+	if !opts.TTY {
+		streamOpts.Stderr = opts.Stderr
+	}
+
+	if f, ok := opts.Stdin.(*os.File); ok && opts.TTY && term.IsTerminal(int(f.Fd())) {
+		state, err := term.MakeRaw(int(f.Fd()))
+		if err != nil {
+			return errors.Wrap(err, "setting terminal to raw mode")
+		}
+
+		defer func() { _ = term.Restore(int(f.Fd()), state) }()
+
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+
+		streamOpts.TerminalSizeQueue = newTerminalSizeQueue(ctx, int(f.Fd()))
+	}
+
+	return executor.StreamWithContext(ctx, streamOpts) //nolint:wrapcheck
+}
+
+// DefaultContainer returns the container set by the kubectl.kubernetes.io/default-container annotation or the
+// first container of the pod.
+func DefaultContainer(pod *corev1.Pod) string {
+	if name := pod.Annotations[defaultContainerAnnotation]; name != "" {
+		return name
+	}
+
+	if len(pod.Spec.Containers) == 0 {
+		return ""
+	}
+
+	return pod.Spec.Containers[0].Name
+}
+
+// ExitCode returns the exit code of the remote command if err was caused by a non-zero exit code.
+func ExitCode(err error) (int, bool) {
+	var exitErr utilexec.ExitError
+	if errors.As(err, &exitErr) {
+		return exitErr.ExitStatus(), true
+	}
+
+	return 0, false
+}
+
+// PortForward forwards the ports ("local:remote") to the pod until the context is canceled. The ready channel is
+// closed once the ports are listening. The status messages are written to out.
+func (c *Client) PortForward(
+	ctx context.Context, pod string, ports []string, ready chan struct{}, out, errOut io.Writer,
+) error {
+	fw, err := c.PortForwarder(ctx, pod, ports, ready, out, errOut)
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(fw.ForwardPorts(), "forwarding ports")
+}
+
+// PortForwarder returns a forwarder of the ports ("local:remote") to the pod on 127.0.0.1 which stops when the
+// context is canceled. Use local port 0 to listen on a random port and GetPorts to get it once ready is closed.
+func (c *Client) PortForwarder(
+	ctx context.Context, pod string, ports []string, ready chan struct{}, out, errOut io.Writer,
+) (*portforward.PortForwarder, error) {
+	transport, upgrader, err := spdy.RoundTripperFor(c.Config)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating round tripper")
+	}
+
+	req := c.Clientset.CoreV1().RESTClient().Post().
+		Resource("pods").
+		Namespace(c.Namespace).
+		Name(pod).
+		SubResource("portforward")
+
+	dialer := spdy.NewDialer(upgrader, &http.Client{Transport: transport}, http.MethodPost, req.URL())
+
+	stop := make(chan struct{})
+
+	go func() {
+		<-ctx.Done()
+		close(stop)
+	}()
+
+	if ready == nil {
+		ready = make(chan struct{})
+	}
+
+	fw, err := portforward.NewOnAddresses(dialer, []string{"127.0.0.1"}, ports, stop, ready, out, errOut)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating port forwarder")
+	}
+
+	return fw, nil
+}
+
+// terminalSizeQueue reports the size of the local terminal whenever it changes.
+type terminalSizeQueue struct {
+	ctx  context.Context //nolint:containedctx
+	fd   int
+	last remotecommand.TerminalSize
+}
+
+func newTerminalSizeQueue(ctx context.Context, fd int) *terminalSizeQueue {
+	return &terminalSizeQueue{ctx: ctx, fd: fd}
+}
+
+// Next blocks until the size of the terminal changes. It returns nil when the session ends.
+func (q *terminalSizeQueue) Next() *remotecommand.TerminalSize {
+	for {
+		width, height, err := term.GetSize(q.fd)
+		if err == nil {
+			size := remotecommand.TerminalSize{Width: uint16(width), Height: uint16(height)}
+			if size != q.last {
+				q.last = size
+
+				return &size
+			}
+		}
+
+		select {
+		case <-q.ctx.Done():
+			return nil
+		case <-time.After(terminalSizePollInterval):
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package kube
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	utilexec "k8s.io/client-go/util/exec"
+)
+
+type KubeTestSuite struct {
+	suite.Suite
+	client *Client
+}
+
+func TestKubeTestSuite(t *testing.T) {
+	suite.Run(t, new(KubeTestSuite))
+}
+
+func (suite *KubeTestSuite) SetupTest() {
+	suite.client = &Client{
+		Clientset: fake.NewSimpleClientset(
+			pod("env", "main-0", "main", "php-fpm", "nginx"),
+			pod("env", "main-1", "main", "php-fpm"),
+			pod("env", "db-0", "db", "db"),
+			pod("other", "main-0", "main", "php-fpm"),
+		),
+		Namespace: "env",
+	}
+}
+
+func (suite *KubeTestSuite) TestListPods() {
+	tests := []struct {
+		name     string
+		selector string
+		want     []string
+	}{
+		{
+			name:     "component selector",
+			selector: "reward.itg.cloud/component=main",
+			want:     []string{"main-0", "main-1"},
+		},
+		{
+			name:     "no selector lists the namespace",
+			selector: "",
+			want:     []string{"db-0", "main-0", "main-1"},
+		},
+		{
+			name:     "no match",
+			selector: "reward.itg.cloud/component=redis",
+			want:     nil,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			pods, err := suite.client.ListPods(context.Background(), tt.selector)
+			suite.Require().NoError(err)
+
+			var names []string
+			for _, p := range pods {
+				names = append(names, p.Name)
+			}
+
+			suite.ElementsMatch(tt.want, names)
+		})
+	}
+}
+
+func (suite *KubeTestSuite) TestGetPod() {
+	p, err := suite.client.GetPod(context.Background(), "db-0")
+	suite.Require().NoError(err)
+	suite.Equal("db", p.Spec.Containers[0].Name)
+
+	_, err = suite.client.GetPod(context.Background(), "missing")
+	suite.Error(err)
+}
+
+func (suite *KubeTestSuite) TestDefaultContainer() {
+	p := pod("env", "main-0", "main", "php-fpm", "nginx")
+	suite.Equal("php-fpm", DefaultContainer(p))
+
+	p.Annotations = map[string]string{defaultContainerAnnotation: "nginx"}
+	suite.Equal("nginx", DefaultContainer(p))
+
+	suite.Equal("", DefaultContainer(&corev1.Pod{}))
+}
+
+func (suite *KubeTestSuite) TestExitCode() {
+	_, ok := ExitCode(nil)
+	suite.False(ok)
+
+	_, ok = ExitCode(context.Canceled)
+	suite.False(ok)
+
+	code, ok := ExitCode(errors.Wrap(utilexec.CodeExitError{Err: errors.New("exit"), Code: 3}, "running"))
+	suite.True(ok)
+	suite.Equal(3, code)
+}
+
+func pod(namespace, name, component string, containers ...string) *corev1.Pod {
+	p := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    map[string]string{"reward.itg.cloud/component": component},
+		},
+	}
+
+	for _, c := range containers {
+		p.Spec.Containers = append(p.Spec.Containers, corev1.Container{Name: c})
+	}
+
+	return p
+}
@@ -2,7 +2,6 @@ package kubectl
 
 import (
 	"container/list"
-	"fmt"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -32,30 +31,38 @@ func (c *Client) RunCommand(args []string, opts ...shell.Opt) (output []byte, er
 }
 
 type Options struct {
-	TokenCacheDir    string
-	ClusterServer    string
-	ClusterCAData    []byte
-	OidcIssuerURL    string
-	OidcClientID     string
-	OidcClientSecret string
+	// Name is the name of the context, cluster and user entries. It defaults to "default".
+	Name string
+	// Namespace is the default namespace of the context.
+	Namespace     string
+	ClusterServer string
+	ClusterCAData []byte
+	// Exec is the credential plugin which provides the token of the user.
+	Exec *kube.ExecConfig
 }
 
 func (c *Client) NewKubeConfig(opts *Options) ([]byte, error) {
+	name := opts.Name
+	if name == "" {
+		name = "default"
+	}
+
 	conf := kube.Config{
 		APIVersion:     "v1",
-		CurrentContext: "default",
+		CurrentContext: name,
 		Contexts: []kube.NamedContext{
 			{
-				Name: "default",
+				Name: name,
 				Context: kube.Context{
-					Cluster:  "default",
-					AuthInfo: "default",
+					Cluster:   name,
+					AuthInfo:  name,
+					Namespace: opts.Namespace,
 				},
 			},
 		},
 		Clusters: []kube.NamedCluster{
 			{
-				Name: "default",
+				Name: name,
 				Cluster: kube.Cluster{
 					Server:                   opts.ClusterServer,
 					CertificateAuthorityData: opts.ClusterCAData,
@@ -64,25 +71,9 @@ func (c *Client) NewKubeConfig(opts *Options) ([]byte, error) {
 		},
 		AuthInfos: []kube.NamedAuthInfo{
 			{
-				Name: "default",
+				Name: name,
 				AuthInfo: kube.AuthInfo{
-					Exec: &kube.ExecConfig{
-						Command: "kubectl",
-						Args: []string{
-							"oidc-login",
-							"get-token",
-							"--oidc-extra-scope=openid",
-							"--oidc-extra-scope=email",
-							"--oidc-extra-scope=profile",
-							fmt.Sprintf("--token-cache-dir=%s", opts.TokenCacheDir),
-							fmt.Sprintf("--oidc-issuer-url=%s", opts.OidcIssuerURL),
-							fmt.Sprintf("--oidc-client-id=%s", opts.OidcClientID),
-							fmt.Sprintf("--oidc-client-secret=%s", opts.OidcClientSecret),
-						},
-						APIVersion:         "client.authentication.k8s.io/v1beta1",
-						InteractiveMode:    "IfAvailable",
-						ProvideClusterInfo: false,
-					},
+					Exec: opts.Exec,
 				},
 			},
 		},
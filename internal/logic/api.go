@@ -0,0 +1,68 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+)
+
+// doAPIRequest sends a JSON request to the Reward Cloud API for the operations which the generated SDK cannot
+// express. The response is decoded into out if it is not nil.
+func (c *Client) doAPIRequest(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return errors.Wrap(err, "marshalling request")
+		}
+
+		r = bytes.NewReader(b)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.Endpoint()+path, r)
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+
+	req.Header.Set("Accept", "application/json")
+
+	switch method {
+	case http.MethodPatch:
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+	default:
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	if token, ok := ctx.Value(rewardcloud.ContextAccessToken).(string); ok {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	httpClient := c.RewardCloud.GetConfig().HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "%s %s", method, path)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+
+		return errors.Errorf("%s %s: %s: %s", method, path, resp.Status, string(b))
+	}
+
+	if out == nil {
+		return nil
+	}
+
+	return errors.Wrapf(json.NewDecoder(resp.Body).Decode(out), "decoding response of %s", path)
+}
@@ -1,12 +1,14 @@
 package logic
 
 import (
+	"bytes"
 	"context"
 	"encoding/base64"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strconv"
 	"strings"
 
@@ -15,7 +17,7 @@ import (
 	"github.com/rewardenv/reward-cloud-cli/internal/kubectl"
 	"github.com/rewardenv/reward-cloud-cli/internal/shell"
 	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
-	"github.com/rewardenv/reward/pkg/util"
+	log "github.com/sirupsen/logrus"
 )
 
 type Client struct {
@@ -131,6 +133,24 @@ func (c *Client) getProjectByID(ctx context.Context, id string) (get *rewardclou
 	return project, nil
 }
 
+func (c *Client) getProjectType(
+	ctx context.Context, project *rewardcloud.ProjectProjectOutput,
+) (*rewardcloud.ProjectType, error) {
+	projectTypeVersion, _, err := c.RewardCloud.ProjectTypeVersionApi.ApiProjectTypeVersionsIdGet(
+		ctx, GetIDFromPath(project.GetProjectTypeVersion())).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting project type version")
+	}
+
+	projectType, _, err := c.RewardCloud.ProjectTypeApi.ApiProjectTypesIdGet(
+		ctx, GetIDFromPath(projectTypeVersion.GetProjectType())).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting project type")
+	}
+
+	return projectType, nil
+}
+
 func (c *Client) getEnvironment(ctx context.Context) (*rewardcloud.EnvironmentEnvironmentOutput, error) {
 	ctx, err := c.prepareContext(ctx)
 	if err != nil {
@@ -172,37 +192,138 @@ func (c *Client) getStateNameByID(ctx context.Context, id string) (string, error
 	return state.GetName(), nil
 }
 
-func (c *Client) prepareKubeconfig(cluster *rewardcloud.Cluster) (*os.File, error) {
-	cacert, err := base64.StdEncoding.DecodeString(cluster.GetClusterCertificateAuthorityData())
+// checkEnvironmentRunning returns an error if the environment is not in running state.
+func (c *Client) checkEnvironmentRunning(
+	ctx context.Context, environment *rewardcloud.EnvironmentEnvironmentOutput,
+) error {
+	state, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
 	if err != nil {
-		return nil, errors.Wrap(err, "decoding cluster CA data")
+		return errors.Wrap(err, "getting environment state")
 	}
 
-	kubeconfig, err := c.Kubectl.NewKubeConfig(&kubectl.Options{
-		TokenCacheDir:    filepath.Join(c.CacheDir(), "kubectl", "oidc-login"),
-		ClusterServer:    cluster.GetClusterServer(),
-		ClusterCAData:    cacert,
-		OidcIssuerURL:    cluster.GetOidcIssuerUrl(),
-		OidcClientID:     cluster.GetOidcClientID(),
-		OidcClientSecret: cluster.GetOidcClientSecret(),
-	})
+	if !strings.EqualFold(state, EnvStateRunning) {
+		return errors.Errorf("environment is not running: state = %s", state)
+	}
+
+	return nil
+}
+
+// isProductionEnvironment returns true if the name or the codename of the environment matches the production
+// environment pattern.
+func (c *Client) isProductionEnvironment(environment *rewardcloud.EnvironmentEnvironmentOutput) (bool, error) {
+	re, err := regexp.Compile(c.GetString("production_environment_pattern"))
 	if err != nil {
-		return nil, errors.Wrap(err, "creating kube config")
+		return false, errors.Wrap(err, "compiling production environment pattern")
 	}
 
-	tmpFile, err := os.CreateTemp(c.CacheDir(), fmt.Sprintf("%s-", c.AppName()))
+	return re.MatchString(environment.GetName()) || re.MatchString(environment.GetCodeName()), nil
+}
+
+// confirmEnvironmentName asks the user to type the name of the environment to confirm a destructive action.
+func (c *Client) confirmEnvironmentName(environment *rewardcloud.EnvironmentEnvironmentOutput, action string) error {
+	log.Warnf("You are about to %s environment %q.", action, environment.GetName())
+
+	val, err := GetValueFromPrompt("Type the name of the environment to continue")
 	if err != nil {
-		return nil, fmt.Errorf("failed to create temporary file: %w", err)
+		return errors.Wrap(err, "getting confirmation")
 	}
 
-	c.TmpFiles.PushBack(tmpFile.Name())
+	if val != environment.GetName() {
+		return errors.New("environment name does not match, aborting")
+	}
 
-	err = util.CreateDirAndWriteToFile(kubeconfig, tmpFile.Name())
+	return nil
+}
+
+// guardProductionEnvironment asks for a typed confirmation if the environment looks like a production one.
+func (c *Client) guardProductionEnvironment(environment *rewardcloud.EnvironmentEnvironmentOutput, action string) error {
+	production, err := c.isProductionEnvironment(environment)
 	if err != nil {
-		return nil, errors.Wrap(err, "creating kube config file")
+		return err
+	}
+
+	if !production {
+		return nil
 	}
 
-	return tmpFile, nil
+	return c.confirmEnvironmentName(environment, action)
+}
+
+// prepareKubeconfig returns the path of the cached kubeconfig of the cluster. The file is only rewritten when its
+// content changes, e.g. when the server, the CA or the OIDC settings of the cluster are updated.
+func (c *Client) prepareKubeconfig(cluster *rewardcloud.Cluster) (string, error) {
+	kubeconfig, err := c.newKubeconfig(cluster, "", "")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(c.CacheDir(), "kubeconfig", fmt.Sprintf("%d.yaml", cluster.GetId()))
+
+	current, err := os.ReadFile(path)
+	if err == nil && bytes.Equal(current, kubeconfig) {
+		return path, errors.Wrap(os.Chmod(path, 0o600), "setting kube config file permissions")
+	}
+
+	if err := writeFileAtomic(path, kubeconfig); err != nil {
+		return "", errors.Wrap(err, "writing kube config file")
+	}
+
+	log.Debugf("Kubeconfig of cluster %s written to %s", cluster.GetName(), path)
+
+	return path, nil
+}
+
+// writeFileAtomic writes data to a temporary file with 0600 permissions next to path and moves it to its place,
+// so concurrent readers never see a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".tmp-")
+	if err != nil {
+		return errors.Wrap(err, "creating temporary file")
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		_ = tmp.Close()
+
+		return errors.Wrap(err, "writing temporary file")
+	}
+
+	if err := tmp.Close(); err != nil {
+		return errors.Wrap(err, "closing temporary file")
+	}
+
+	return errors.Wrap(os.Rename(tmp.Name(), path), "renaming temporary file")
+}
+
+// newKubeconfig returns a kubeconfig for the cluster which authenticates using the credential command. The
+// context, cluster and user entries are called name and the context uses the namespace.
+func (c *Client) newKubeconfig(cluster *rewardcloud.Cluster, name, namespace string) ([]byte, error) {
+	cacert, err := base64.StdEncoding.DecodeString(cluster.GetClusterCertificateAuthorityData())
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding cluster CA data")
+	}
+
+	if err := c.storeOidcClientSecret(cluster); err != nil {
+		return nil, err
+	}
+
+	kubeconfig, err := c.Kubectl.NewKubeConfig(&kubectl.Options{
+		Name:          name,
+		Namespace:     namespace,
+		ClusterServer: cluster.GetClusterServer(),
+		ClusterCAData: cacert,
+		Exec:          c.credentialExecConfig(cluster.GetId(), cluster.GetOidcIssuerUrl(), cluster.GetOidcClientID()),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "creating kube config")
+	}
+
+	return kubeconfig, nil
 }
 
 func (c *Client) getRcContext(ctx context.Context) *config.RcContext {
@@ -241,25 +362,13 @@ func (c *Client) prepareContext(ctx context.Context) (context.Context, error) {
 	return context.WithValue(ctx, config.ContextKey{}, rcContext), nil
 }
 
+// CheckKubectl checks that kubectl is installed. The kubeconfigs authenticate using the credential command of this
+// binary, so kubelogin is not needed.
 func (c *Client) CheckKubectl() error {
 	_, err := exec.LookPath("kubectl")
 	if err != nil {
 		return errors.Errorf("%s: please install kubectl", err)
 	}
 
-	out, err := c.Shell.ExecuteWithOptions(
-		"kubectl",
-		[]string{"oidc-login", "--version"},
-		shell.WithSuppressOutput(true),
-		shell.WithCatchOutput(true),
-	)
-	if err != nil {
-		return errors.Errorf("%s: cannot run kubectl oidc-login: %s", err, string(out))
-	}
-
-	if !strings.Contains(string(out), "kubelogin version") {
-		return errors.New("cannot determine kubelogin version: please install kubelogin")
-	}
-
 	return nil
 }
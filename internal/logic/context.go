@@ -39,16 +39,16 @@ func (c *ContextClient) RunCmdContextList(cmd *cobra.Command, args []string) err
 	}
 
 	if c.GetBool("full") {
-		return c.listContexts(conf, WithFull())
+		return c.listContexts(cmd.Context(), conf, WithFull())
 	}
 
 	log.Info("Listing available contexts...")
 
-	return c.listContexts(conf)
+	return c.listContexts(cmd.Context(), conf)
 }
 
 func (c *ContextClient) RunCmdContextCreate(cmd *cobra.Command, args []string) error {
-	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(context.Background())
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "checking token")
 	}
@@ -96,7 +96,7 @@ func (c *ContextClient) RunCmdContextDelete(cmd *cobra.Command, args []string) e
 		return nil
 	}
 
-	err = c.listContexts(conf)
+	err = c.listContexts(cmd.Context(), conf)
 	if err != nil {
 		return errors.Wrap(err, "listing contexts")
 	}
@@ -133,7 +133,7 @@ func (c *ContextClient) RunCmdContextSelect(cmd *cobra.Command, args []string) e
 
 	log.Info("Select a context to use...")
 
-	err = c.listContexts(conf)
+	err = c.listContexts(cmd.Context(), conf)
 	if err != nil {
 		return errors.Wrap(err, "listing contexts")
 	}
@@ -178,7 +178,7 @@ func (c *ContextClient) RunCmdContextCheck(cmd *cobra.Command, args []string) er
 		return nil
 	}
 
-	ctx, err := c.prepareContext(context.Background())
+	ctx, err := c.prepareContext(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "preparing context")
 	}
@@ -246,7 +246,7 @@ func WithFull() ListContextOption {
 	}
 }
 
-func (c *ContextClient) listContexts(conf *config.Config, opts ...ListContextOption) error {
+func (c *ContextClient) listContexts(ctx context.Context, conf *config.Config, opts ...ListContextOption) error {
 	o := &ListContextOptions{}
 	for _, opt := range opts {
 		opt(o)
@@ -266,16 +266,16 @@ func (c *ContextClient) listContexts(conf *config.Config, opts ...ListContextOpt
 		}
 
 		if o.Full {
-			ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(context.Background())
+			authCtx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
 			if err != nil {
 				return errors.Wrap(err, "checking token")
 			}
 
-			orgname, err := c.getOrganizationByID(ctx, confCtx.Organization)
+			orgname, err := c.getOrganizationByID(authCtx, confCtx.Organization)
 			if err != nil {
 				return errors.Wrap(err, "getting organization by ID")
 			}
-			teamname, err := c.getTeamByID(ctx, confCtx.Team)
+			teamname, err := c.getTeamByID(authCtx, confCtx.Team)
 			if err != nil {
 				return errors.Wrap(err, "getting team by ID")
 			}
@@ -0,0 +1,220 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/kube"
+)
+
+// remotePathPattern matches the component:path form of remote paths. Windows drive letters (C:\) don't match
+// as components have at least two characters.
+var remotePathPattern = regexp.MustCompile(`^([a-z0-9][a-z0-9-]+):(.*)$`)
+
+// remotePath is a path in a container of a component of the environment.
+type remotePath struct {
+	Component string
+	Path      string
+}
+
+type CopyClient struct {
+	*Client
+}
+
+func NewCopyClient(c *config.App) *CopyClient {
+	return &CopyClient{New(c)}
+}
+
+func (c *CopyClient) RunCmdCopy(cmd *cobra.Command, args []string) error {
+	src, dst := args[0], args[1]
+	srcRemote, dstRemote := parseRemotePath(src), parseRemotePath(dst)
+
+	switch {
+	case srcRemote == nil && dstRemote == nil:
+		return errors.New("one of the paths must be a remote path (component:path)")
+	case srcRemote != nil && dstRemote != nil:
+		return errors.New("copying between remote paths is not supported")
+	}
+
+	remote := srcRemote
+	if remote == nil {
+		remote = dstRemote
+	}
+
+	if remote.Path == "" {
+		return errors.Errorf("missing path of component %s", remote.Component)
+	}
+
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "logging in")
+	}
+
+	target, err := c.prepareKubeTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	pod, err := c.selectPod(ctx, target, remote.Component, c.GetString("cp_pod"))
+	if err != nil {
+		return errors.Wrap(err, "getting pod")
+	}
+
+	defaultContainer := remote.Component
+	if remote.Component == ComponentMain {
+		projectType, err := c.getProjectType(ctx, target.Project)
+		if err != nil {
+			return errors.Wrap(err, "getting project type")
+		}
+
+		defaultContainer = strings.ToLower(projectType.GetName())
+	}
+
+	container, err := selectContainer(pod, c.GetString("cp_container"), defaultContainer)
+	if err != nil {
+		return err
+	}
+
+	if srcRemote == nil {
+		err = c.upload(ctx, target, pod.Name, container, src, dstRemote.Path)
+	} else {
+		err = c.download(ctx, target, pod.Name, container, srcRemote.Path, dst)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Copied %s to %s", args[0], args[1])
+
+	return nil
+}
+
+// upload copies the local file or directory to dest in the container. If dest is an existing directory, src is
+// copied into it, otherwise src is copied to dest.
+func (c *CopyClient) upload(ctx context.Context, target *kubeTarget, podname, container, src, dest string) error {
+	if _, err := os.Stat(src); err != nil {
+		return errors.Wrap(err, "checking source")
+	}
+
+	// The archive has a single entry named after src which is moved to dest if dest is not a directory.
+	const script = `if [ -d "$0" ]; then exec tar -xf - -C "$0"; fi
+tmp=$(mktemp -d) && mkdir -p "$(dirname "$0")" && tar -xf - -C "$tmp" && rm -rf "$0" && mv "$tmp/$1" "$0"
+status=$?; rm -rf "$tmp"; exit $status`
+
+	err := c.streamToPod(ctx, target, podname, container, fmt.Sprintf("Uploading %s...", src), tarPath(src), nil,
+		"sh", "-c", script, dest, filepath.Base(src),
+	)
+
+	return errors.Wrap(err, "uploading")
+}
+
+// download copies the file or directory src of the container to dest. If dest is an existing directory, src is
+// copied into it, otherwise src is copied to dest.
+func (c *CopyClient) download(ctx context.Context, target *kubeTarget, podname, container, src, dest string) error {
+	src = path.Clean(src)
+
+	run := func(ctx context.Context, stdout io.Writer, command ...string) error {
+		var stderr bytes.Buffer
+
+		err := target.Kube.Exec(ctx, podname, kube.ExecOptions{
+			Container: container,
+			Command:   command,
+			Stdout:    stdout,
+			Stderr:    &stderr,
+		})
+
+		return errors.Wrapf(err, "running %s: %s", command[0], strings.TrimSpace(stderr.String()))
+	}
+
+	// The size is only used to render the progress.
+	size := int64(-1)
+
+	var out bytes.Buffer
+	if err := run(ctx, &out, "du", "-sk", src); err != nil {
+		return errors.Wrapf(err, "checking %s", src)
+	}
+
+	if fields := strings.Fields(out.String()); len(fields) > 0 {
+		if kb, err := strconv.ParseInt(fields[0], 10, 64); err == nil {
+			size = kb * 1024
+		}
+	}
+
+	extractDir, rename := dest, ""
+
+	if fi, err := os.Stat(dest); err != nil || !fi.IsDir() {
+		extractDir, err = os.MkdirTemp(filepath.Dir(dest), ".cp-")
+		if err != nil {
+			return errors.Wrap(err, "creating temporary directory")
+		}
+		defer os.RemoveAll(extractDir)
+
+		rename = filepath.Join(extractDir, path.Base(src))
+	}
+
+	err := runWithProgress(ctx, fmt.Sprintf("Downloading %s...", src),
+		func(ctx context.Context, report func(written, total int64)) error {
+			pr, pw := io.Pipe()
+
+			go func() {
+				_ = pw.CloseWithError(run(ctx, pw, "tar", "-cf", "-", "-C", path.Dir(src), path.Base(src)))
+			}()
+
+			// Stop the stream if the extraction stops early.
+			defer func() { _ = pr.Close() }()
+
+			return extractTar(&contextReader{ctx: ctx, r: newProgressReader(pr, size, report)}, extractDir)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "downloading")
+	}
+
+	if rename == "" {
+		return nil
+	}
+
+	if err := os.RemoveAll(dest); err != nil {
+		return errors.Wrap(err, "removing existing destination")
+	}
+
+	return errors.Wrap(os.Rename(rename, dest), "moving to destination")
+}
+
+// parseRemotePath returns the remote path if s is in the component:path form, otherwise nil.
+func parseRemotePath(s string) *remotePath {
+	m := remotePathPattern.FindStringSubmatch(s)
+	if m == nil {
+		return nil
+	}
+
+	return &remotePath{Component: m[1], Path: m[2]}
+}
+
+// contextReader stops reading when the context is canceled.
+type contextReader struct {
+	ctx context.Context //nolint:containedctx
+	r   io.Reader
+}
+
+func (r *contextReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err //nolint:wrapcheck
+	}
+
+	return r.r.Read(b) //nolint:wrapcheck
+}
@@ -0,0 +1,152 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/spf13/cobra"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	clientauthv1beta1 "k8s.io/client-go/pkg/apis/clientauthentication/v1beta1"
+	kubeapi "k8s.io/client-go/tools/clientcmd/api/v1"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/oidc"
+)
+
+const execCredentialAPIVersion = "client.authentication.k8s.io/v1beta1"
+
+type CredentialClient struct {
+	*Client
+}
+
+func NewCredentialClient(c *config.App) *CredentialClient {
+	return &CredentialClient{New(c)}
+}
+
+// RunCmdCredential prints an ExecCredential with the OIDC id token of the cluster. It is called by kubectl and
+// client-go as the exec credential plugin of the generated kubeconfigs.
+func (c *CredentialClient) RunCmdCredential(cmd *cobra.Command, args []string) error {
+	issuerURL, clientID := c.GetString("credential_issuer_url"), c.GetString("credential_client_id")
+	if issuerURL == "" || clientID == "" {
+		return errors.New("please specify the issuer url and the client id")
+	}
+
+	flow := c.GetString(fmt.Sprintf("%s_oidc_flow", c.ConfigPrefix()))
+	if flow != oidc.FlowAuto && flow != oidc.FlowBrowser && flow != oidc.FlowDevice {
+		return errors.Errorf("invalid login flow %q, use one of: %s, %s, %s",
+			flow, oidc.FlowAuto, oidc.FlowBrowser, oidc.FlowDevice)
+	}
+
+	clientSecret, err := c.oidcClientSecret(c.GetInt32("credential_cluster_id"))
+	if err != nil {
+		return err
+	}
+
+	token, err := oidc.GetToken(cmd.Context(), issuerURL, clientID,
+		oidc.WithClientSecret(clientSecret),
+		oidc.WithCacheDir(c.oidcCacheDir()),
+		oidc.WithFlow(flow),
+	)
+	if err != nil {
+		return errors.Wrap(err, "getting oidc token")
+	}
+
+	expiry := metav1.NewTime(token.Expiry)
+
+	cred := clientauthv1beta1.ExecCredential{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: execCredentialAPIVersion,
+			Kind:       "ExecCredential",
+		},
+		Status: &clientauthv1beta1.ExecCredentialStatus{
+			Token:               token.IDToken,
+			ExpirationTimestamp: &expiry,
+		},
+	}
+
+	return errors.Wrap(json.NewEncoder(os.Stdout).Encode(cred), "encoding exec credential")
+}
+
+// credentialExecConfig returns the exec credential plugin configuration which calls the credential command of this
+// plugin by name, so the kubeconfig keeps working when the binary is moved or upgraded. The client secret is not
+// part of it, the credential command reads it from the cache of the cluster.
+func (c *Client) credentialExecConfig(clusterID int32, issuerURL, clientID string) *kubeapi.ExecConfig {
+	return &kubeapi.ExecConfig{
+		Command: c.ParentAppName(),
+		Args: []string{
+			c.AppName(),
+			"credential",
+			fmt.Sprintf("--cluster-id=%d", clusterID),
+			fmt.Sprintf("--issuer-url=%s", issuerURL),
+			fmt.Sprintf("--client-id=%s", clientID),
+		},
+		Env: []kubeapi.ExecEnvVar{
+			{Name: strings.ToUpper(fmt.Sprintf("%s_cache_dir", c.ConfigPrefix())), Value: c.CacheDir()},
+		},
+		APIVersion:      execCredentialAPIVersion,
+		InteractiveMode: kubeapi.IfAvailableExecInteractiveMode,
+	}
+}
+
+// oidcClientSecretKey is the config key of the OIDC client secret. It overrides the client secret cached for the
+// cluster.
+func (c *Client) oidcClientSecretKey() string {
+	return fmt.Sprintf("%s_oidc_client_secret", c.ConfigPrefix())
+}
+
+// oidcClientSecretFile is the file which caches the OIDC client secret of the cluster.
+func (c *Client) oidcClientSecretFile(clusterID int32) string {
+	return filepath.Join(c.oidcCacheDir(), "clusters", fmt.Sprintf("%d.secret", clusterID))
+}
+
+// storeOidcClientSecret caches the OIDC client secret of the cluster for the credential command in a file readable
+// only by the user. The cached secret is removed if the cluster does not use a client secret.
+func (c *Client) storeOidcClientSecret(cluster *rewardcloud.Cluster) error {
+	file := c.oidcClientSecretFile(cluster.GetId())
+
+	if cluster.GetOidcClientSecret() == "" {
+		if err := os.Remove(file); err != nil && !os.IsNotExist(err) {
+			return errors.Wrap(err, "removing oidc client secret")
+		}
+
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return errors.Wrap(err, "creating oidc cache directory")
+	}
+
+	return errors.Wrap(os.WriteFile(file, []byte(cluster.GetOidcClientSecret()), 0o600), "writing oidc client secret")
+}
+
+// oidcClientSecret returns the OIDC client secret from the config or from the cache of the cluster. It is empty if
+// the cluster does not use a client secret.
+func (c *Client) oidcClientSecret(clusterID int32) (string, error) {
+	if secret := c.GetString(c.oidcClientSecretKey()); secret != "" {
+		return secret, nil
+	}
+
+	if clusterID == 0 {
+		return "", nil
+	}
+
+	b, err := os.ReadFile(c.oidcClientSecretFile(clusterID))
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+
+	if err != nil {
+		return "", errors.Wrap(err, "reading oidc client secret")
+	}
+
+	return string(b), nil
+}
+
+func (c *Client) oidcCacheDir() string {
+	return filepath.Join(c.CacheDir(), "oidc")
+}
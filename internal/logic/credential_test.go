@@ -0,0 +1,56 @@
+package logic
+
+import (
+	"os"
+	"testing"
+
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type CredentialTestSuite struct {
+	suite.Suite
+}
+
+func TestCredentialTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialTestSuite))
+}
+
+func (suite *CredentialTestSuite) TestOidcClientSecret() {
+	app := config.New("cloud", "reward", "v0.0.1")
+	app.Set(app.ConfigPrefix()+"_cache_dir", suite.T().TempDir())
+
+	c := New(app)
+
+	cluster := rewardcloud.NewCluster()
+	cluster.SetId(7)
+	cluster.SetOidcClientSecret("s3cr3t")
+
+	suite.Require().NoError(c.storeOidcClientSecret(cluster))
+
+	fi, err := os.Stat(c.oidcClientSecretFile(7))
+	suite.Require().NoError(err)
+	suite.Equal(os.FileMode(0o600), fi.Mode().Perm())
+
+	secret, err := c.oidcClientSecret(7)
+	suite.Require().NoError(err)
+	suite.Equal("s3cr3t", secret)
+
+	secret, err = c.oidcClientSecret(8)
+	suite.Require().NoError(err)
+	suite.Empty(secret)
+
+	exec := c.credentialExecConfig(7, "https://sso.example.org", "kubernetes")
+	suite.Equal(c.ParentAppName(), exec.Command)
+	suite.Contains(exec.Args, "--cluster-id=7")
+
+	for _, env := range exec.Env {
+		suite.NotContains(env.Value, "s3cr3t")
+	}
+
+	cluster.SetOidcClientSecret("")
+	suite.Require().NoError(c.storeOidcClientSecret(cluster))
+	suite.NoFileExists(c.oidcClientSecretFile(7))
+}
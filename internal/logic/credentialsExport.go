@@ -0,0 +1,413 @@
+package logic
+
+import (
+	"bytes"
+	"crypto/sha1" //nolint:gosec
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	ExportFormatDotenv    = "dotenv"
+	ExportFormatShell     = "shell"
+	ExportFormatMyCnf     = "my.cnf"
+	ExportFormatJetBrains = "jetbrains"
+	ExportFormatDBeaver   = "dbeaver"
+)
+
+// ExportFormats are the formats the credentials of the environment can be exported to.
+var ExportFormats = []string{
+	ExportFormatDotenv, ExportFormatShell, ExportFormatMyCnf, ExportFormatJetBrains, ExportFormatDBeaver,
+}
+
+// credentialsExport are the credentials of the environment exported for local tooling.
+type credentialsExport struct {
+	// Name is the name of the data source in the IDE configs.
+	Name      string
+	Database  *DatabaseCredentials
+	Endpoints map[string]string
+	// IncludePassword adds the password to the JetBrains data source, which is left out by default as the file is
+	// usually shared in the repository.
+	IncludePassword bool
+}
+
+// variables returns the environment variables of the credentials in a stable order.
+func (e *credentialsExport) variables() [][2]string {
+	d := e.Database
+
+	vars := [][2]string{
+		{"DB_ENGINE", d.Engine},
+		{"DB_HOST", d.Host},
+		{"DB_PORT", strconv.Itoa(d.Port)},
+		{"DB_DATABASE", d.Schema},
+		{"DB_USER", d.User},
+		{"DB_PASSWORD", d.Password},
+		{"DATABASE_URL", d.URL},
+	}
+
+	names := make([]string, 0, len(e.Endpoints))
+	for name := range e.Endpoints {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	for _, name := range names {
+		key := strings.ToUpper(name)
+		if name == "database" {
+			key = "PHPMYADMIN"
+		}
+
+		vars = append(vars, [2]string{key + "_URL", e.Endpoints[name]})
+	}
+
+	return vars
+}
+
+// jdbcURL returns the JDBC connection string of the database.
+func (e *credentialsExport) jdbcURL() string {
+	d := e.Database
+
+	scheme := "mysql"
+
+	switch d.Engine {
+	case DatabaseEngineMariaDB:
+		scheme = "mariadb"
+	case DatabaseEnginePostgreSQL:
+		scheme = "postgresql"
+	}
+
+	return fmt.Sprintf("jdbc:%s://%s/%s", scheme, net.JoinHostPort(d.Host, strconv.Itoa(d.Port)), d.Schema)
+}
+
+// uuid returns a UUID derived from the name, so the data source is replaced when the config is written again.
+func (e *credentialsExport) uuid() string {
+	h := sha1.Sum([]byte(e.Name)) //nolint:gosec
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", h[0:4], h[4:6], h[6:8], h[8:10], h[10:16])
+}
+
+// renderCredentials renders the credentials in the export format.
+func renderCredentials(format string, e *credentialsExport) ([]byte, error) {
+	switch format {
+	case ExportFormatDotenv:
+		return renderVariables(e, "%s=%s\n", quoteDotEnvValue), nil
+	case ExportFormatShell:
+		return renderVariables(e, "export %s=%s\n", shellQuote), nil
+	case ExportFormatMyCnf:
+		return renderMyCnf(e)
+	case ExportFormatJetBrains:
+		return renderJetBrains(e)
+	case ExportFormatDBeaver:
+		return renderDBeaver(e)
+	default:
+		return nil, checkExportFormat(format)
+	}
+}
+
+// checkExportFormat returns an error if the format is set and not one of ExportFormats.
+func checkExportFormat(format string) error {
+	if format == "" {
+		return nil
+	}
+
+	for _, f := range ExportFormats {
+		if format == f {
+			return nil
+		}
+	}
+
+	return errors.Errorf("unsupported export format %q (options: %s)", format, strings.Join(ExportFormats, ", "))
+}
+
+func renderVariables(e *credentialsExport, line string, quote func(string) string) []byte {
+	var b bytes.Buffer
+
+	for _, v := range e.variables() {
+		fmt.Fprintf(&b, line, v[0], quote(v[1]))
+	}
+
+	return b.Bytes()
+}
+
+func renderMyCnf(e *credentialsExport) ([]byte, error) {
+	d := e.Database
+	if d.Engine == DatabaseEnginePostgreSQL {
+		return nil, errors.Errorf("%s is not supported for %s", ExportFormatMyCnf, d.engine().Title())
+	}
+
+	return []byte(fmt.Sprintf("[client]\nhost=%s\nport=%d\nuser=%s\npassword=%s\n\n[mysql]\ndatabase=%s\n",
+		d.Host, d.Port, myCnfQuote(d.User), myCnfQuote(d.Password), myCnfQuote(d.Schema))), nil
+}
+
+// jetbrainsProject is the .idea/dataSources.xml file of the JetBrains IDEs.
+type jetbrainsProject struct {
+	XMLName   xml.Name `xml:"project"`
+	Version   string   `xml:"version,attr"`
+	Component struct {
+		Name        string                `xml:"name,attr"`
+		Format      string                `xml:"format,attr"`
+		DataSources []jetbrainsDataSource `xml:"data-source"`
+	} `xml:"component"`
+}
+
+type jetbrainsDataSource struct {
+	Source      string `xml:"source,attr"`
+	Name        string `xml:"name,attr"`
+	UUID        string `xml:"uuid,attr"`
+	DriverRef   string `xml:"driver-ref"`
+	Synchronize bool   `xml:"synchronize"`
+	JDBCURL     string `xml:"jdbc-url"`
+	WorkingDir  string `xml:"working-dir"`
+}
+
+// renderJetBrains renders a data source of the JetBrains IDEs. dataSources.xml is usually shared in the repository,
+// so only the user is passed in the JDBC URL unless the password is included explicitly. Otherwise the password is
+// kept by the IDE once it is entered.
+func renderJetBrains(e *credentialsExport) ([]byte, error) {
+	d := e.Database
+
+	driver := "mysql.8"
+
+	switch d.Engine {
+	case DatabaseEngineMariaDB:
+		driver = "mariadb"
+	case DatabaseEnginePostgreSQL:
+		driver = "postgresql"
+	}
+
+	query := url.Values{"user": {d.User}}
+	if e.IncludePassword {
+		query.Set("password", d.Password)
+	}
+
+	project := jetbrainsProject{Version: "4"}
+	project.Component.Name = "DataSourceManagerImpl"
+	project.Component.Format = "xml"
+	project.Component.DataSources = []jetbrainsDataSource{{
+		Source:      "LOCAL",
+		Name:        e.Name,
+		UUID:        e.uuid(),
+		DriverRef:   driver,
+		Synchronize: true,
+		JDBCURL:     e.jdbcURL() + "?" + query.Encode(),
+		WorkingDir:  "$ProjectFileDir$",
+	}}
+
+	b, err := xml.MarshalIndent(project, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding data source")
+	}
+
+	return append([]byte(xml.Header), append(b, '\n')...), nil
+}
+
+// renderDBeaver renders the data-sources.json file of a DBeaver project.
+func renderDBeaver(e *credentialsExport) ([]byte, error) {
+	return encodeDBeaver(map[string]interface{}{
+		"folders":     map[string]interface{}{},
+		"connections": map[string]interface{}{e.Name: dbeaverConnection(e)},
+	})
+}
+
+// dbeaverConnection returns the connection of the database in the data-sources.json file of DBeaver.
+func dbeaverConnection(e *credentialsExport) map[string]interface{} {
+	d := e.Database
+
+	provider, driver := "mysql", "mysql8"
+
+	switch d.Engine {
+	case DatabaseEngineMariaDB:
+		driver = "mariaDB"
+	case DatabaseEnginePostgreSQL:
+		provider, driver = "postgresql", "postgres-jdbc"
+	}
+
+	return map[string]interface{}{
+		"provider":      provider,
+		"driver":        driver,
+		"name":          e.Name,
+		"save-password": true,
+		"configuration": map[string]interface{}{
+			"host":       d.Host,
+			"port":       strconv.Itoa(d.Port),
+			"database":   d.Schema,
+			"url":        e.jdbcURL(),
+			"type":       "dev",
+			"auth-model": "native",
+			"user":       d.User,
+			"password":   d.Password,
+		},
+	}
+}
+
+func encodeDBeaver(config map[string]interface{}) ([]byte, error) {
+	b, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, errors.Wrap(err, "encoding data source")
+	}
+
+	return append(b, '\n'), nil
+}
+
+// exportFormatForPath guesses the export format from the name of the file. Unknown files are written as dotenv.
+// JSON files are not guessed, as there are many JSON configs.
+func exportFormatForPath(path string) (string, error) {
+	base := strings.ToLower(filepath.Base(path))
+
+	switch {
+	case strings.HasSuffix(base, ".sh"):
+		return ExportFormatShell, nil
+	case strings.HasSuffix(base, ".cnf"):
+		return ExportFormatMyCnf, nil
+	case strings.HasSuffix(base, ".xml"):
+		return ExportFormatJetBrains, nil
+	case strings.HasSuffix(base, ".json"):
+		return "", errors.Errorf("cannot guess the export format of %s, please set it (e.g. %s)", path,
+			ExportFormatDBeaver)
+	default:
+		return ExportFormatDotenv, nil
+	}
+}
+
+// writeCredentials writes the credentials to the file at path, which is readable only by the user. If format is
+// empty, it is guessed from the name of the file. The credentials are merged into existing dotenv, shell and DBeaver
+// files, other existing files are replaced only if force is set.
+func writeCredentials(path, format string, force bool, e *credentialsExport) error {
+	if format == "" {
+		var err error
+
+		format, err = exportFormatForPath(path)
+		if err != nil {
+			return err
+		}
+	}
+
+	b, err := renderCredentials(format, e)
+	if err != nil {
+		return err
+	}
+
+	existing, err := os.ReadFile(path)
+
+	switch {
+	case errors.Is(err, fs.ErrNotExist), force:
+	case err != nil:
+		return errors.Wrapf(err, "reading %s", path)
+	default:
+		b, err = mergeCredentials(format, existing, b, e)
+		if err != nil {
+			return errors.Wrapf(err, "%s already exists, use --force to replace it", path)
+		}
+	}
+
+	if err := writeFileAtomic(path, b); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+
+	log.Infof("Credentials written to %s (%s)", path, format)
+
+	if format == ExportFormatJetBrains && !e.IncludePassword {
+		log.Info("The password is not written to the data source, enter it in the IDE once (see info --export=dotenv) " +
+			"or use --include-password")
+	}
+
+	return nil
+}
+
+// mergeCredentials merges the rendered credentials into the existing file.
+func mergeCredentials(format string, existing, rendered []byte, e *credentialsExport) ([]byte, error) {
+	switch format {
+	case ExportFormatDotenv, ExportFormatShell:
+		return mergeVariables(existing, rendered), nil
+	case ExportFormatDBeaver:
+		var config map[string]interface{}
+		if err := json.Unmarshal(existing, &config); err != nil {
+			return nil, errors.Wrap(err, "decoding data sources")
+		}
+
+		connections, _ := config["connections"].(map[string]interface{})
+		if connections == nil {
+			connections = make(map[string]interface{})
+		}
+
+		connections[e.Name] = dbeaverConnection(e)
+		config["connections"] = connections
+
+		return encodeDBeaver(config)
+	default:
+		return nil, errors.Errorf("cannot merge the credentials into a %s file", format)
+	}
+}
+
+// mergeVariables replaces the variables of the existing dotenv or shell file with the rendered ones and appends the
+// new variables. Other lines are kept.
+func mergeVariables(existing, rendered []byte) []byte {
+	lines := make(map[string]string)
+
+	var keys []string
+
+	for _, line := range strings.SplitAfter(string(rendered), "\n") {
+		if key := variableKey(line); key != "" {
+			lines[key] = line
+			keys = append(keys, key)
+		}
+	}
+
+	var b bytes.Buffer
+
+	written := make(map[string]bool)
+
+	for _, line := range strings.SplitAfter(string(existing), "\n") {
+		key := variableKey(line)
+		if _, ok := lines[key]; !ok {
+			b.WriteString(line)
+
+			continue
+		}
+
+		if !written[key] {
+			b.WriteString(lines[key])
+			written[key] = true
+		}
+	}
+
+	if b.Len() > 0 && !bytes.HasSuffix(b.Bytes(), []byte("\n")) {
+		b.WriteByte('\n')
+	}
+
+	for _, key := range keys {
+		if !written[key] {
+			b.WriteString(lines[key])
+		}
+	}
+
+	return b.Bytes()
+}
+
+// variableKey returns the name of the variable set in the line of a dotenv or shell file.
+func variableKey(line string) string {
+	line = strings.TrimSpace(line)
+	if strings.HasPrefix(line, "#") {
+		return ""
+	}
+
+	key, _, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+	if !ok {
+		return ""
+	}
+
+	return strings.TrimSpace(key)
+}
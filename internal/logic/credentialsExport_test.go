@@ -0,0 +1,195 @@
+package logic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type CredentialsExportTestSuite struct {
+	suite.Suite
+}
+
+func TestCredentialsExportTestSuite(t *testing.T) {
+	suite.Run(t, new(CredentialsExportTestSuite))
+}
+
+func (suite *CredentialsExportTestSuite) export(engine string) *credentialsExport {
+	d := &DatabaseCredentials{
+		Engine:   engine,
+		Host:     "127.0.0.1",
+		Schema:   "magento",
+		User:     "magento",
+		Password: `it's "secret"`,
+	}
+	d.setPort(d.engine().Port())
+
+	return &credentialsExport{
+		Name:      "reward-demo-staging",
+		Database:  d,
+		Endpoints: map[string]string{"frontend": "https://staging.example.org"},
+	}
+}
+
+func (suite *CredentialsExportTestSuite) TestRenderCredentials() {
+	tests := []struct {
+		name        string
+		format      string
+		engine      string
+		password    bool
+		contains    []string
+		notContains []string
+		wantErr     bool
+	}{
+		{
+			name:     "dotenv",
+			format:   ExportFormatDotenv,
+			engine:   DatabaseEngineMySQL,
+			contains: []string{"DB_PORT=3306\n", `DB_PASSWORD="it's \"secret\""`, "FRONTEND_URL="},
+		},
+		{
+			name:     "shell",
+			format:   ExportFormatShell,
+			engine:   DatabaseEngineMySQL,
+			contains: []string{"export DB_HOST=", "export DB_PASSWORD="},
+		},
+		{
+			name:     "my.cnf",
+			format:   ExportFormatMyCnf,
+			engine:   DatabaseEngineMariaDB,
+			contains: []string{"[client]\n", "port=3306\n", `password="it's "secret""`},
+		},
+		{
+			name:    "my.cnf for postgresql",
+			format:  ExportFormatMyCnf,
+			engine:  DatabaseEnginePostgreSQL,
+			wantErr: true,
+		},
+		{
+			name:        "jetbrains without the password",
+			format:      ExportFormatJetBrains,
+			engine:      DatabaseEnginePostgreSQL,
+			contains:    []string{"jdbc:postgresql://127.0.0.1:5432/magento?user=magento", "postgresql</driver-ref>"},
+			notContains: []string{"secret", "password"},
+		},
+		{
+			name:     "jetbrains with the password",
+			format:   ExportFormatJetBrains,
+			engine:   DatabaseEngineMySQL,
+			password: true,
+			contains: []string{"jdbc:mysql://127.0.0.1:3306/magento?password=it%27s+%22secret%22&amp;user=magento"},
+		},
+		{
+			name:     "dbeaver",
+			format:   ExportFormatDBeaver,
+			engine:   DatabaseEngineMariaDB,
+			contains: []string{`"driver": "mariaDB"`, `"port": "3306"`},
+		},
+		{
+			name:    "unknown",
+			format:  "toml",
+			engine:  DatabaseEngineMySQL,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			e := suite.export(tt.engine)
+			e.IncludePassword = tt.password
+
+			b, err := renderCredentials(tt.format, e)
+			if tt.wantErr {
+				suite.Error(err)
+
+				return
+			}
+
+			suite.Require().NoError(err)
+
+			for _, s := range tt.contains {
+				suite.Contains(string(b), s)
+			}
+
+			for _, s := range tt.notContains {
+				suite.NotContains(string(b), s)
+			}
+		})
+	}
+}
+
+func (suite *CredentialsExportTestSuite) TestExportFormatForPath() {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: ".env", want: ExportFormatDotenv},
+		{path: "config/db.env.local", want: ExportFormatDotenv},
+		{path: "credentials.SH", want: ExportFormatShell},
+		{path: "/home/user/.my.cnf", want: ExportFormatMyCnf},
+		{path: ".idea/dataSources.xml", want: ExportFormatJetBrains},
+		{path: "data-sources.json", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.path, func() {
+			got, err := exportFormatForPath(tt.path)
+			if tt.wantErr {
+				suite.Error(err)
+
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, got)
+		})
+	}
+}
+
+func (suite *CredentialsExportTestSuite) TestMergeVariables() {
+	existing := "# local settings\nAPP_ENV=dev\nDB_HOST=db\nexport DB_PORT=3306\nDB_HOST=other"
+	rendered := "DB_HOST=127.0.0.1\nDB_PORT=13306\nDB_USER=magento\n"
+
+	suite.Equal(
+		"# local settings\nAPP_ENV=dev\nDB_HOST=127.0.0.1\nDB_PORT=13306\nDB_USER=magento\n",
+		string(mergeVariables([]byte(existing), []byte(rendered))),
+	)
+}
+
+func (suite *CredentialsExportTestSuite) TestWriteCredentials() {
+	dir := suite.T().TempDir()
+	e := suite.export(DatabaseEngineMySQL)
+
+	dotenv := filepath.Join(dir, ".env")
+	suite.Require().NoError(os.WriteFile(dotenv, []byte("APP_ENV=dev\nDB_HOST=db\n"), 0o600))
+	suite.Require().NoError(writeCredentials(dotenv, "", false, e))
+
+	b, err := os.ReadFile(dotenv)
+	suite.Require().NoError(err)
+	suite.Contains(string(b), "APP_ENV=dev\nDB_HOST=127.0.0.1\n")
+
+	dbeaver := filepath.Join(dir, "data-sources.json")
+	suite.Require().NoError(os.WriteFile(dbeaver, []byte(`{"connections":{"local":{"name":"local"}}}`), 0o600))
+	suite.Error(writeCredentials(dbeaver, "", false, e))
+	suite.Require().NoError(writeCredentials(dbeaver, ExportFormatDBeaver, false, e))
+
+	b, err = os.ReadFile(dbeaver)
+	suite.Require().NoError(err)
+
+	var config struct {
+		Connections map[string]interface{} `json:"connections"`
+	}
+
+	suite.Require().NoError(json.Unmarshal(b, &config))
+	suite.Contains(config.Connections, "local")
+	suite.Contains(config.Connections, e.Name)
+
+	jetbrains := filepath.Join(dir, "dataSources.xml")
+	suite.Require().NoError(os.WriteFile(jetbrains, []byte("<project/>"), 0o600))
+	suite.Error(writeCredentials(jetbrains, "", false, e))
+	suite.Require().NoError(writeCredentials(jetbrains, "", true, e))
+}
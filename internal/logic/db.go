@@ -0,0 +1,218 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+)
+
+type DBClient struct {
+	*EnvClient
+}
+
+func NewDBClient(c *config.App) *DBClient {
+	return &DBClient{NewEnvClient(c)}
+}
+
+func (c *DBClient) RunCmdDBPull(cmd *cobra.Command, args []string) error {
+	local, err := c.getLocalEnvironment()
+	if err != nil {
+		return errors.Wrap(err, "checking local environment")
+	}
+
+	values := c.GetStringSlice("db_pull_post_import_sql")
+	if cmd.Flags().Changed("post-import-sql") {
+		values, err = cmd.Flags().GetStringArray("post-import-sql")
+		if err != nil {
+			return errors.Wrap(err, "reading post-import sql flag")
+		}
+	}
+
+	statements, err := readPostImportSQL(values)
+	if err != nil {
+		return err
+	}
+
+	profileName := c.GetString("db_pull_strip_profile")
+
+	profile, err := c.getStripProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	file, err := c.pullExportedData(ctx, DataTypeDatabase, c.GetString("db_pull_from_export"),
+		func(ctx context.Context) (*rewardcloud.ExportedData, error) {
+			return c.exportDatabase(ctx, c.GetBool("db_pull_strip_database") || (profile != nil && profile.ServerStrip))
+		},
+	)
+	if err != nil {
+		return err
+	}
+
+	if profile != nil {
+		if err := stripDump(ctx, file, profileName, profile); err != nil {
+			return err
+		}
+	}
+
+	var transform func(io.Reader) (io.Reader, error)
+	if strings.HasSuffix(file, ".gz") {
+		transform = gunzip
+	}
+
+	err = streamToCommand(ctx, fmt.Sprintf("Importing %s into %s...", filepath.Base(file), local.Name),
+		openFile(file), transform,
+		func(_ context.Context, stdin io.Reader) ([]byte, error) {
+			return c.runParentApp([]string{"db", "import"},
+				shell.WithStdin(stdin),
+				shell.WithSuppressOutput(true),
+				shell.WithCatchOutput(true),
+			)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "importing database")
+	}
+
+	if len(statements) > 0 {
+		log.Infof("Running %d post-import SQL statement(s)...", len(statements))
+
+		out, err := c.runParentApp([]string{"db", "import"},
+			shell.WithStdin(strings.NewReader(strings.Join(statements, ";\n")+";\n")),
+			shell.WithSuppressOutput(true),
+			shell.WithCatchOutput(true),
+		)
+		if err != nil {
+			return errors.Wrapf(err, "running post-import sql, command output: %s", string(out))
+		}
+	}
+
+	if !c.GetBool("db_pull_keep") {
+		if err := os.Remove(file); err != nil {
+			log.Warnf("Cannot remove %s: %s", file, err)
+		}
+	}
+
+	log.Infof("Database imported into local environment %s", local.Name)
+
+	return nil
+}
+
+// RunCmdDBURL prints the connection string of the database of the environment. The port is the --local-port flag,
+// the local port of the running background port-forward of the database or the port of the database engine.
+func (c *DBClient) RunCmdDBURL(cmd *cobra.Command, args []string) error {
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	project, err := c.getProject(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting project")
+	}
+
+	environment, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	credentials, err := c.getLocalDatabaseCredentials(ctx, project, environment, c.GetInt("db_url_local_port"))
+	if err != nil {
+		return err
+	}
+
+	fmt.Println(credentials.URL)
+
+	return nil
+}
+
+// getLocalDatabaseCredentials returns the credentials of the database of the environment. Unless port is set, it
+// uses the local port of the running background port-forward of the database or the port of the database engine.
+func (c *Client) getLocalDatabaseCredentials(
+	ctx context.Context, project *rewardcloud.ProjectProjectOutput, environment *rewardcloud.EnvironmentEnvironmentOutput,
+	port int,
+) (*DatabaseCredentials, error) {
+	credentials, err := c.getDatabaseCredentials(ctx, project, environment)
+	if err != nil {
+		return nil, err
+	}
+
+	if port == 0 {
+		port, err = c.forwardedDatabasePort(c.getRcContext(ctx).Name, environment.GetName())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if port == 0 {
+		port = credentials.engine().Port()
+
+		log.Debugf("No port-forward of the database is running, using port %d", port)
+	}
+
+	credentials.setPort(port)
+
+	return credentials, nil
+}
+
+// forwardedDatabasePort returns the local port of the background port-forward of the database of the environment
+// or 0 if it is not forwarded.
+func (c *Client) forwardedDatabasePort(contextName, environment string) (int, error) {
+	states, err := c.readPortForwardStates()
+	if err != nil {
+		return 0, err
+	}
+
+	for _, s := range states {
+		if s.Context != contextName || s.Environment != environment {
+			continue
+		}
+
+		for _, f := range s.Services {
+			if f.Service == "db" {
+				return addressPort(f.LocalAddress)
+			}
+		}
+	}
+
+	return 0, nil
+}
+
+// readPostImportSQL returns the post-import SQL statements. Values starting with @ are read from the file.
+func readPostImportSQL(values []string) ([]string, error) {
+	statements := make([]string, 0, len(values))
+
+	for _, v := range values {
+		if strings.HasPrefix(v, "@") {
+			b, err := os.ReadFile(strings.TrimPrefix(v, "@"))
+			if err != nil {
+				return nil, errors.Wrap(err, "reading post-import sql file")
+			}
+
+			v = string(b)
+		}
+
+		v = strings.TrimSuffix(strings.TrimSpace(v), ";")
+		if v != "" {
+			statements = append(statements, v)
+		}
+	}
+
+	return statements, nil
+}
@@ -0,0 +1,361 @@
+package logic
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+)
+
+const databaseTunnelTimeout = 30 * time.Second
+
+// databaseClients are the supported clients of the database engines. The first installed one is the default.
+var databaseClients = map[string][]string{
+	DatabaseEngineMySQL:      {"mysql", "mariadb", "mycli"},
+	DatabaseEngineMariaDB:    {"mariadb", "mysql", "mycli"},
+	DatabaseEnginePostgreSQL: {"psql", "pgcli"},
+}
+
+// databaseDumpers are the dump tools of the database engines. The first installed one is used.
+var databaseDumpers = map[string][]string{
+	DatabaseEngineMySQL:      {"mysqldump", "mariadb-dump"},
+	DatabaseEngineMariaDB:    {"mariadb-dump", "mysqldump"},
+	DatabaseEnginePostgreSQL: {"pg_dump"},
+}
+
+// RunCmdDBConnect opens an ephemeral port-forward to the database and runs a database client in it. The arguments
+// are passed to the client.
+func (c *DBClient) RunCmdDBConnect(cmd *cobra.Command, args []string) error {
+	// Ctrl+C is handled by the client (e.g. to cancel the running query), so the port-forward is not stopped by
+	// interrupts, only when the client exits.
+	credentials, stop, err := c.openDatabaseTunnel(context.Background())
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	engine := credentials.engine()
+
+	client, err := findDatabaseTool(c.GetString("db_connect_client"), databaseClients[engine.Name], engine)
+	if err != nil {
+		return err
+	}
+
+	toolArgs, env, cleanup, err := c.databaseToolArgs(client, credentials)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	_, err = c.Shell.ExecuteWithOptions(client, append(toolArgs, args...), shell.WithEnv(env...))
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitCodeError{Code: exitErr.ExitCode()}
+	}
+
+	return errors.Wrapf(err, "running %s", client)
+}
+
+// RunCmdDBDump dumps the database through an ephemeral port-forward. Dumps written to .gz files are compressed.
+func (c *DBClient) RunCmdDBDump(cmd *cobra.Command, args []string) error {
+	include := c.GetStringSlice("db_dump_include_table")
+	exclude := c.GetStringSlice("db_dump_exclude_table")
+
+	if len(include) > 0 && len(exclude) > 0 {
+		return errors.New("--include-table and --exclude-table cannot be used together")
+	}
+
+	credentials, stop, err := c.openDatabaseTunnel(cmd.Context())
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	engine := credentials.engine()
+
+	dumper, err := findDatabaseTool("", databaseDumpers[engine.Name], engine)
+	if err != nil {
+		return err
+	}
+
+	toolArgs, env, cleanup, err := c.databaseToolArgs(dumper, credentials)
+	if err != nil {
+		return err
+	}
+	defer cleanup()
+
+	toolArgs = append(toolArgs, databaseDumpArgs(dumper, credentials.Schema, include, exclude)...)
+
+	file := c.GetString("db_dump_output")
+	if file == "" {
+		file = fmt.Sprintf("%s-%s.sql.gz", credentials.Schema, time.Now().Format("20060102-150405"))
+	}
+
+	log.Infof("Dumping %s database %s to %s...", engine.Title(), credentials.Schema, file)
+
+	if err := dumpDatabase(cmd.Context(), file, dumper, toolArgs, env); err != nil {
+		_ = os.Remove(file)
+
+		return err
+	}
+
+	fi, err := os.Stat(file)
+	if err != nil {
+		return errors.Wrap(err, "checking dump")
+	}
+
+	log.Infof("Database dumped to %s (%s)", file, formatSize(fi.Size()))
+
+	return nil
+}
+
+// openDatabaseTunnel forwards a free local port to the database of the environment until the returned function is
+// called. It returns the credentials of the database with the local port.
+func (c *DBClient) openDatabaseTunnel(ctx context.Context) (*DatabaseCredentials, func(), error) {
+	ctx, err := c.prepareContext(ctx)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "preparing context")
+	}
+
+	target, err := c.prepareKubeTarget(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := c.checkEnvironmentRunning(ctx, target.Environment); err != nil {
+		return nil, nil, err
+	}
+
+	credentials, err := c.getDatabaseCredentials(ctx, target.Project, target.Environment)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	specs, err := c.parseTunnelSpecs([]string{"db:auto"})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	applyDatabaseEngine(specs, credentials.engine())
+
+	tunnels, err := listenTunnels(specs)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	credentials.setPort(tunnels[0].LocalPort)
+
+	ctx, cancel := context.WithCancel(ctx)
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+
+		_ = c.runTunnels(ctx, target, tunnels, false)
+	}()
+
+	stop := func() {
+		cancel()
+		<-done
+	}
+
+	timeout := time.After(databaseTunnelTimeout)
+
+	for tunnels[0].status().State != TunnelStateConnected {
+		select {
+		case <-timeout:
+			stop()
+
+			return nil, nil, errors.Errorf("cannot connect to the database in %s", databaseTunnelTimeout)
+		case <-ctx.Done():
+			stop()
+
+			return nil, nil, errors.Wrap(ctx.Err(), "connecting to the database")
+		case <-time.After(tunnelRefreshInterval):
+		}
+	}
+
+	return credentials, stop, nil
+}
+
+// findDatabaseTool returns the requested tool or the first installed one of the candidates.
+func findDatabaseTool(requested string, candidates []string, engine DatabaseEngine) (string, error) {
+	if requested != "" {
+		supported := false
+
+		for _, candidate := range candidates {
+			if candidate == requested {
+				supported = true
+
+				break
+			}
+		}
+
+		if !supported {
+			return "", errors.Errorf("%s is not supported for %s, use one of: %s",
+				requested, engine.Title(), strings.Join(candidates, ", "))
+		}
+
+		candidates = []string{requested}
+	}
+
+	for _, candidate := range candidates {
+		if _, err := exec.LookPath(candidate); err == nil {
+			return candidate, nil
+		}
+	}
+
+	return "", errors.Errorf("cannot find %s in PATH, please install it", strings.Join(candidates, " or "))
+}
+
+// databaseToolArgs returns the connection arguments and environment of the tool. The password is written to a
+// temporary file readable only by the user, which is removed by the returned function.
+func (c *DBClient) databaseToolArgs(tool string, credentials *DatabaseCredentials) (
+	args []string, env []string, cleanup func(), err error,
+) {
+	var content string
+
+	if credentials.Engine == DatabaseEnginePostgreSQL {
+		content = fmt.Sprintf("%s:%d:%s:%s:%s\n", pgpassEscape(credentials.Host), credentials.Port,
+			pgpassEscape(credentials.Schema), pgpassEscape(credentials.User), pgpassEscape(credentials.Password))
+	} else {
+		content = fmt.Sprintf("[client]\nhost=%s\nport=%d\nuser=%s\npassword=%s\n", credentials.Host,
+			credentials.Port, myCnfQuote(credentials.User), myCnfQuote(credentials.Password))
+	}
+
+	file, err := c.writeTmpFile(content)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	cleanup = func() {
+		_ = os.Remove(file)
+	}
+
+	switch tool {
+	case "psql", "pgcli", "pg_dump":
+		args = []string{"-h", credentials.Host, "-p", strconv.Itoa(credentials.Port), "-U", credentials.User}
+		if tool != "pg_dump" {
+			args = append(args, "-d", credentials.Schema)
+		}
+
+		env = []string{"PGPASSFILE=" + file}
+	case "mycli":
+		args = []string{"--defaults-file=" + file, "--database=" + credentials.Schema}
+	case "mysqldump", "mariadb-dump":
+		// The option file has to be the first argument.
+		args = []string{"--defaults-extra-file=" + file}
+	default:
+		args = []string{"--defaults-extra-file=" + file, "--database=" + credentials.Schema}
+	}
+
+	return args, env, cleanup, nil
+}
+
+// databaseDumpArgs returns the arguments of the dump tool to dump the schema with the table filters.
+func databaseDumpArgs(dumper, schema string, include, exclude []string) []string {
+	if dumper == "pg_dump" {
+		args := []string{"--no-owner", "--no-privileges", "--no-password"}
+
+		for _, t := range include {
+			args = append(args, "--table="+t)
+		}
+
+		for _, t := range exclude {
+			args = append(args, "--exclude-table="+t)
+		}
+
+		return append(args, schema)
+	}
+
+	args := []string{"--single-transaction", "--quick", "--no-tablespaces"}
+
+	for _, t := range exclude {
+		args = append(args, fmt.Sprintf("--ignore-table=%s.%s", schema, t))
+	}
+
+	args = append(args, schema)
+
+	return append(args, include...)
+}
+
+// dumpDatabase runs the dump tool and writes its output to file. The output is compressed if the file ends with .gz.
+func dumpDatabase(ctx context.Context, file, dumper string, args, env []string) error {
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "creating file")
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+
+	var zw *gzip.Writer
+	if strings.HasSuffix(file, ".gz") {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+
+	cmd := exec.CommandContext(ctx, dumper, args...)
+	cmd.Env = append(os.Environ(), env...)
+	cmd.Stdout = w
+	cmd.Stderr = os.Stderr
+
+	log.Debugf("Executing command: %s %s", dumper, strings.Join(args, " "))
+
+	if err := cmd.Run(); err != nil {
+		return errors.Wrapf(err, "running %s", dumper)
+	}
+
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return errors.Wrap(err, "compressing dump")
+		}
+	}
+
+	return errors.Wrap(f.Close(), "closing file")
+}
+
+// writeTmpFile writes content to a temporary file in the cache directory, which is readable only by the user.
+// Files left behind are removed by config.App.SweepTmpFiles.
+func (c *DBClient) writeTmpFile(content string) (string, error) {
+	if err := os.MkdirAll(c.CacheDir(), 0o700); err != nil {
+		return "", errors.Wrap(err, "creating cache directory")
+	}
+
+	f, err := os.CreateTemp(c.CacheDir(), c.AppName()+"-*")
+	if err != nil {
+		return "", errors.Wrap(err, "creating temporary file")
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString(content); err != nil {
+		_ = os.Remove(f.Name())
+
+		return "", errors.Wrap(err, "writing temporary file")
+	}
+
+	return f.Name(), nil
+}
+
+// myCnfQuote quotes a value of a MySQL option file. Only the surrounding quotes are stripped by the clients, so the
+// value may contain quotes, but backslashes start escape sequences.
+func myCnfQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `\`, `\\`) + `"`
+}
+
+// pgpassEscape escapes a field of a PostgreSQL password file.
+func pgpassEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `:`, `\:`).Replace(s)
+}
@@ -0,0 +1,155 @@
+package logic
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type DBConnectTestSuite struct {
+	suite.Suite
+}
+
+func TestDBConnectTestSuite(t *testing.T) {
+	suite.Run(t, new(DBConnectTestSuite))
+}
+
+func (suite *DBConnectTestSuite) TestDatabaseToolArgs() {
+	app := config.New("cloud", "reward", "v0.0.1")
+	app.Set(app.ConfigPrefix()+"_cache_dir", suite.T().TempDir())
+
+	c := NewDBClient(app)
+
+	mysql := &DatabaseCredentials{
+		Engine: DatabaseEngineMySQL, Host: "127.0.0.1", Port: 13306, Schema: "magento", User: "magento",
+		Password: `p@ss\word`,
+	}
+	postgres := &DatabaseCredentials{
+		Engine: DatabaseEnginePostgreSQL, Host: "127.0.0.1", Port: 15432, Schema: "app", User: "app",
+		Password: `p:ss\word`,
+	}
+
+	tests := []struct {
+		name        string
+		tool        string
+		credentials *DatabaseCredentials
+		want        []string
+		wantEnv     bool
+		wantFile    string
+	}{
+		{
+			name:        "mysql",
+			tool:        "mysql",
+			credentials: mysql,
+			want:        []string{"--defaults-extra-file=", "--database=magento"},
+			wantFile:    "[client]\nhost=127.0.0.1\nport=13306\nuser=\"magento\"\npassword=\"p@ss\\\\word\"\n",
+		},
+		{
+			name:        "mycli",
+			tool:        "mycli",
+			credentials: mysql,
+			want:        []string{"--defaults-file=", "--database=magento"},
+		},
+		{
+			name:        "mysqldump",
+			tool:        "mysqldump",
+			credentials: mysql,
+			want:        []string{"--defaults-extra-file="},
+		},
+		{
+			name:        "psql",
+			tool:        "psql",
+			credentials: postgres,
+			want:        []string{"-h", "127.0.0.1", "-p", "15432", "-U", "app", "-d", "app"},
+			wantEnv:     true,
+			wantFile:    "127.0.0.1:15432:app:app:p\\:ss\\\\word\n",
+		},
+		{
+			name:        "pg_dump",
+			tool:        "pg_dump",
+			credentials: postgres,
+			want:        []string{"-h", "127.0.0.1", "-p", "15432", "-U", "app"},
+			wantEnv:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			args, env, cleanup, err := c.databaseToolArgs(tt.tool, tt.credentials)
+			suite.Require().NoError(err)
+
+			var file string
+
+			if tt.wantEnv {
+				suite.Require().Len(env, 1)
+				suite.Equal(tt.want, args)
+
+				file = env[0][len("PGPASSFILE="):]
+			} else {
+				suite.Empty(env)
+				suite.Require().Len(args, len(tt.want))
+				suite.Equal(tt.want[1:], args[1:])
+				suite.Require().Contains(args[0], tt.want[0])
+
+				file = args[0][len(tt.want[0]):]
+			}
+
+			fi, err := os.Stat(file)
+			suite.Require().NoError(err)
+			suite.Equal(os.FileMode(0o600), fi.Mode().Perm())
+
+			if tt.wantFile != "" {
+				b, err := os.ReadFile(file)
+				suite.Require().NoError(err)
+				suite.Equal(tt.wantFile, string(b))
+			}
+
+			cleanup()
+			suite.NoFileExists(file)
+		})
+	}
+}
+
+func (suite *DBConnectTestSuite) TestDatabaseDumpArgs() {
+	tests := []struct {
+		name    string
+		dumper  string
+		include []string
+		exclude []string
+		want    []string
+	}{
+		{
+			name:   "mysqldump",
+			dumper: "mysqldump",
+			want:   []string{"--single-transaction", "--quick", "--no-tablespaces", "magento"},
+		},
+		{
+			name:    "mysqldump with tables",
+			dumper:  "mariadb-dump",
+			include: []string{"catalog_product_entity", "sales_order"},
+			exclude: []string{"customer_log"},
+			want: []string{
+				"--single-transaction", "--quick", "--no-tablespaces", "--ignore-table=magento.customer_log",
+				"magento", "catalog_product_entity", "sales_order",
+			},
+		},
+		{
+			name:    "pg_dump with tables",
+			dumper:  "pg_dump",
+			include: []string{"orders"},
+			exclude: []string{"logs"},
+			want: []string{
+				"--no-owner", "--no-privileges", "--no-password", "--table=orders", "--exclude-table=logs", "magento",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.Equal(tt.want, databaseDumpArgs(tt.dumper, "magento", tt.include, tt.exclude))
+		})
+	}
+}
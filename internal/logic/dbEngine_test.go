@@ -0,0 +1,60 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type DBEngineTestSuite struct {
+	suite.Suite
+}
+
+func TestDBEngineTestSuite(t *testing.T) {
+	suite.Run(t, new(DBEngineTestSuite))
+}
+
+func (suite *DBEngineTestSuite) TestApplyDatabaseEngine() {
+	specs := func() []tunnelSpec {
+		return []tunnelSpec{
+			{Name: "db", Service: defaultForwardServices["db"], LocalPort: 3306},
+			{Name: "db", Service: defaultForwardServices["db"], LocalPort: 13306, explicitLocalPort: true},
+			{Name: "db-replica", Service: ForwardService{Component: ComponentDB, Port: 3307}, LocalPort: 3307},
+			{Name: "redis", Service: defaultForwardServices["redis"], LocalPort: 6379},
+		}
+	}
+
+	tests := []struct {
+		name   string
+		engine DatabaseEngine
+		want   []tunnelSpec
+	}{
+		{
+			name:   "mysql",
+			engine: DatabaseEngine{Name: DatabaseEngineMySQL},
+			want:   specs(),
+		},
+		{
+			name:   "postgresql",
+			engine: DatabaseEngine{Name: DatabaseEnginePostgreSQL, Version: "15"},
+			want: []tunnelSpec{
+				{Name: "db", Service: ForwardService{Component: ComponentDB, Port: 5432}, LocalPort: 5432},
+				{
+					Name: "db", Service: ForwardService{Component: ComponentDB, Port: 5432},
+					LocalPort: 13306, explicitLocalPort: true,
+				},
+				{Name: "db-replica", Service: ForwardService{Component: ComponentDB, Port: 3307}, LocalPort: 3307},
+				{Name: "redis", Service: defaultForwardServices["redis"], LocalPort: 6379},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			got := specs()
+			applyDatabaseEngine(got, tt.engine)
+
+			suite.Equal(tt.want, got)
+		})
+	}
+}
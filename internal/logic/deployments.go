@@ -0,0 +1,161 @@
+package logic
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward/pkg/util"
+	"github.com/spf13/cobra"
+)
+
+const (
+	deploymentHistoryFile  = "deployments.json"
+	deploymentHistoryLimit = 200
+)
+
+const (
+	DeploymentResultSuccess = "success"
+	DeploymentResultFailed  = "failed"
+	DeploymentResultAborted = "aborted"
+)
+
+// Deployment is a build and deploy started with this CLI. The API does not keep a deployment history, so the
+// deployments are recorded locally.
+type Deployment struct {
+	ID            int        `json:"id" yaml:"id"`
+	EnvironmentID string     `json:"environmentId" yaml:"environmentId"`
+	Environment   string     `json:"environment" yaml:"environment"`
+	Ref           string     `json:"ref" yaml:"ref"`
+	Commit        string     `json:"commit,omitempty" yaml:"commit,omitempty"`
+	Author        string     `json:"author" yaml:"author"`
+	StartedAt     time.Time  `json:"startedAt" yaml:"startedAt"`
+	FinishedAt    *time.Time `json:"finishedAt,omitempty" yaml:"finishedAt,omitempty"`
+	Result        string     `json:"result" yaml:"result"`
+}
+
+func (c *EnvClient) RunCmdEnvDeployments(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_deployments_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	deployments, err := c.readDeployments()
+	if err != nil {
+		return err
+	}
+
+	if !c.GetBool("env_deployments_all") {
+		ctx, err := c.prepareContext(cmd.Context())
+		if err != nil {
+			return errors.Wrap(err, "preparing context")
+		}
+
+		envID := c.getRcContext(ctx).Environment
+		filtered := deployments[:0]
+
+		for _, d := range deployments {
+			if d.EnvironmentID == envID {
+				filtered = append(filtered, d)
+			}
+		}
+
+		deployments = filtered
+	}
+
+	if limit := c.GetInt("env_deployments_limit"); limit > 0 && len(deployments) > limit {
+		deployments = deployments[len(deployments)-limit:]
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, deployments)
+	}
+
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"ID", "Environment", "Ref", "Commit", "Author", "Started At", "Duration", "Result"})
+
+	for i := len(deployments) - 1; i >= 0; i-- {
+		d := deployments[i]
+
+		commit := d.Commit
+		if len(commit) > 8 {
+			commit = commit[:8]
+		}
+
+		duration := "-"
+		if d.FinishedAt != nil {
+			duration = d.FinishedAt.Sub(d.StartedAt).Round(time.Second).String()
+		}
+
+		t.AppendRow(table.Row{
+			d.ID, d.Environment, d.Ref, commit, d.Author, formatTime(&d.StartedAt), duration, d.Result,
+		})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+func (c *Client) deploymentHistoryPath() string {
+	return filepath.Join(c.AppHomeDir(), deploymentHistoryFile)
+}
+
+// readDeployments returns the recorded deployments, the oldest first.
+func (c *Client) readDeployments() ([]Deployment, error) {
+	b, err := os.ReadFile(c.deploymentHistoryPath())
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "reading deployment history")
+	}
+
+	var deployments []Deployment
+	if err := json.Unmarshal(b, &deployments); err != nil {
+		return nil, errors.Wrap(err, "unmarshalling deployment history")
+	}
+
+	return deployments, nil
+}
+
+// recordDeployment appends the deployment to the history. Only the last deploymentHistoryLimit deployments are kept.
+func (c *Client) recordDeployment(d Deployment) error {
+	deployments, err := c.readDeployments()
+	if err != nil {
+		return err
+	}
+
+	d.ID = 1
+	if len(deployments) > 0 {
+		d.ID = deployments[len(deployments)-1].ID + 1
+	}
+
+	deployments = append(deployments, d)
+	if len(deployments) > deploymentHistoryLimit {
+		deployments = deployments[len(deployments)-deploymentHistoryLimit:]
+	}
+
+	b, err := json.MarshalIndent(deployments, "", "  ")
+	if err != nil {
+		return errors.Wrap(err, "marshalling deployment history")
+	}
+
+	return errors.Wrap(util.CreateDirAndWriteToFile(b, c.deploymentHistoryPath(), 0o600), "writing deployment history")
+}
+
+// deploymentResult returns the result of a deployment based on the error returned while waiting for it.
+func deploymentResult(err error) string {
+	switch {
+	case err == nil:
+		return DeploymentResultSuccess
+	case errors.Is(err, ErrOperationAborted):
+		return DeploymentResultAborted
+	default:
+		return DeploymentResultFailed
+	}
+}
@@ -0,0 +1,58 @@
+package logic
+
+import (
+	"sort"
+)
+
+const (
+	diffAdded   = "added"
+	diffChanged = "changed"
+	diffRemoved = "removed"
+)
+
+// ValueDiff is a value which differs between two sets of values. Missing values are nil.
+type ValueDiff struct {
+	Key    string  `json:"key" yaml:"key"`
+	Status string  `json:"status" yaml:"status"`
+	Value  *string `json:"value" yaml:"value"`
+	Other  *string `json:"other" yaml:"other"`
+}
+
+// diffValues returns the keys which are missing from one of the maps or have different values, sorted by key.
+// Keys missing from other are reported as removed, keys missing from values as added.
+func diffValues(values, other map[string]string) []ValueDiff {
+	var diff []ValueDiff
+
+	for key, value := range values {
+		value := value
+
+		otherValue, ok := other[key]
+
+		switch {
+		case !ok:
+			diff = append(diff, ValueDiff{Key: key, Status: diffRemoved, Value: &value})
+		case otherValue != value:
+			diff = append(diff, ValueDiff{Key: key, Status: diffChanged, Value: &value, Other: &otherValue})
+		}
+	}
+
+	for key, otherValue := range other {
+		otherValue := otherValue
+
+		if _, ok := values[key]; !ok {
+			diff = append(diff, ValueDiff{Key: key, Status: diffAdded, Other: &otherValue})
+		}
+	}
+
+	sort.Slice(diff, func(i, j int) bool { return diff[i].Key < diff[j].Key })
+
+	return diff
+}
+
+func valueOrDash(s *string) string {
+	if s == nil {
+		return "-"
+	}
+
+	return *s
+}
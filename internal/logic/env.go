@@ -3,17 +3,13 @@ package logic
 import (
 	"context"
 	"fmt"
-	"os"
 	"strconv"
 	"strings"
 	"time"
 
-	"golang.org/x/sync/errgroup"
-
-	tea "github.com/charmbracelet/bubbletea"
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/internal/config"
-	"github.com/rewardenv/reward-cloud-cli/internal/ui"
+	"github.com/rewardenv/reward-cloud-cli/internal/strip"
 	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
 	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
@@ -21,6 +17,20 @@ import (
 
 const (
 	EnvStateRunning = "running"
+	EnvStateStopped = "stopped"
+
+	// envStatePending is shown while a new environment has no state yet.
+	envStatePending = "pending"
+
+	DataTypeDatabase = "Database"
+	DataTypeMedia    = "Media"
+)
+
+const (
+	// localGitHead is the default value of --ref which deploys the local git checkout.
+	localGitHead = "HEAD"
+	// serverRef is the value of --ref which deploys the ref configured for the environment.
+	serverRef = "server"
 )
 
 type EnvClient struct {
@@ -32,7 +42,7 @@ func NewEnvClient(c *config.App) *EnvClient {
 }
 
 func (c *EnvClient) RunCmdEnvBuildAndDeploy(cmd *cobra.Command, args []string) error {
-	ctx, err := c.prepareContext(context.Background())
+	ctx, err := c.prepareContext(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "preparing context")
 	}
@@ -42,253 +52,214 @@ func (c *EnvClient) RunCmdEnvBuildAndDeploy(cmd *cobra.Command, args []string) e
 		return errors.Wrap(err, "getting environment")
 	}
 
-	patch := rewardcloud.EnvironmentEnvironmentOutput{
-		Id: env.Id,
+	ref, commit := c.GetString("build_and_deploy_ref"), ""
+
+	switch ref {
+	case serverRef:
+		ref = ""
+	case localGitHead, "":
+		local, err := c.localGitRef(!c.GetBool("build_and_deploy_skip_push_check"))
+
+		switch {
+		case errors.Is(err, ErrNotGitRepository) && !cmd.Flags().Changed("ref"):
+			log.Infof("Not in a git repository, deploying the ref configured for the environment")
+
+			ref = ""
+		case err != nil:
+			return err
+		default:
+			ref, commit = local.Ref, local.Commit
+		}
+	}
+
+	envID := c.getRcContext(ctx).Environment
+
+	startedAt := time.Now()
+
+	if ref == "" {
+		err = c.buildAndDeploy(ctx, env)
+	} else {
+		err = c.buildAndDeployRef(ctx, env, ref)
+	}
+
+	finishedAt := time.Now()
+	if ref == "" {
+		ref = "-"
+	}
+
+	if recordErr := c.recordDeployment(Deployment{
+		EnvironmentID: envID,
+		Environment:   env.GetName(),
+		Ref:           ref,
+		Commit:        commit,
+		Author:        c.gitAuthor(),
+		StartedAt:     startedAt,
+		FinishedAt:    &finishedAt,
+		Result:        deploymentResult(err),
+	}); recordErr != nil {
+		log.Warnf("Cannot record deployment: %s", recordErr)
 	}
 
-	_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdbuildAndDeployPatch(
-		ctx, c.getRcContext(ctx).Environment).EnvironmentEnvironmentOutput(patch).Execute()
 	if err != nil {
 		return errors.Wrap(err, "building environment")
 	}
 
-	p := tea.NewProgram(ui.NewModel("Building environment..."))
+	log.Infof("Build and deploy finished")
 
-	var g errgroup.Group
-	g.Go(func() error {
-		// Initial delay
-		initialized := false
-		for {
-			pause := time.Duration(3000) * time.Millisecond
-			time.Sleep(pause)
+	return nil
+}
 
-			ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
-			if err != nil {
-				return errors.Wrap(err, "logging in")
-			}
+// buildAndDeployRef builds and deploys the git ref. The API has no dedicated field for the git ref, so it is passed to
+// the build in the environment variable configured by branch_env_var, which is restored when the build finished.
+func (c *EnvClient) buildAndDeployRef(ctx context.Context, env *rewardcloud.EnvironmentEnvironmentOutput, ref string,
+) error {
+	key := c.GetString("branch_env_var")
 
-			environment, err := c.getEnvironment(ctx)
-			if err != nil {
-				return errors.Wrap(err, "getting environment")
-			}
+	restore, err := c.overrideEnvironmentEnvVar(ctx, strconv.FormatInt(int64(env.GetId()), 10), key, ref)
+	if err != nil {
+		return errors.Wrap(err, "setting git ref")
+	}
 
-			state, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
-			if err != nil {
-				return errors.Wrap(err, "getting state")
-			}
+	log.Infof("Deploying %s to environment %s", ref, env.GetName())
 
-			if strings.ToLower(state) != EnvStateRunning {
-				initialized = true
-			}
+	err = c.buildAndDeploy(ctx, env)
 
-			if strings.ToLower(state) == EnvStateRunning && initialized {
-				p.Send(ui.ResultMsg{Ready: true})
+	// The variable is restored even if the command was interrupted.
+	if restoreErr := restore(detachedContext{ctx}); restoreErr != nil {
+		log.Warnf("Cannot restore %s of environment %s: %s", key, env.GetName(), restoreErr)
+	}
 
-				return nil
-			}
+	return err
+}
 
-			p.Send(ui.ResultMsg{Msg: fmt.Sprintf("Environment status: %s", state)})
-		}
-	})
+// buildAndDeploy starts the build and deploy of the environment and waits until it is running again.
+func (c *Client) buildAndDeploy(ctx context.Context, env *rewardcloud.EnvironmentEnvironmentOutput) error {
+	envID := strconv.FormatInt(int64(env.GetId()), 10)
 
-	if _, err := p.Run(); err != nil {
-		log.Errorf("Error running program: %s", err)
-		os.Exit(1)
+	patch := rewardcloud.EnvironmentEnvironmentOutput{
+		Id: env.Id,
 	}
 
-	if err := g.Wait(); err != nil {
-		log.Errorf("Error waiting for program: %s", err)
-		os.Exit(1)
+	_, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdbuildAndDeployPatch(
+		ctx, envID).EnvironmentEnvironmentOutput(patch).Execute()
+	if err != nil {
+		return errors.Wrap(err, "starting build")
 	}
 
-	log.Infof("Build and deploy finished")
-
-	return nil
+	return c.trackEnvironmentOperation(ctx, envID, "Building environment...", EnvStateRunning)
 }
 
 func (c *EnvClient) RunCmdEnvExportDB(cmd *cobra.Command, args []string) error {
-	const datatype = "Database"
-
-	ctx, err := c.prepareContext(context.Background())
+	ctx, err := c.prepareContext(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "preparing context")
 	}
 
-	stripDatabase := rewardcloud.NewNullableBool(rewardcloud.PtrBool(c.GetBool("strip_database")))
-	post := rewardcloud.EnvironmentEnvironmentInput{
-		IsStripDatabase: *stripDatabase,
+	profileName := c.GetString("export_db_strip_profile")
+
+	profile, err := c.getStripProfile(profileName)
+	if err != nil {
+		return err
 	}
 
-	_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdexportDatabasePut(
-		ctx, c.getRcContext(ctx).Environment).EnvironmentEnvironmentInput(post).Execute()
+	exported, err := c.exportDatabase(ctx, c.GetBool("strip_database") || (profile != nil && profile.ServerStrip))
 	if err != nil {
-		return errors.Wrap(err, "exporting database")
+		return err
 	}
 
-	p := tea.NewProgram(ui.NewModel(fmt.Sprintf("Exporting %s...", datatype)))
-	result := ""
-
-	var g errgroup.Group
-	g.Go(func() error {
-		// Initial delay
-		initialized := false
-		for {
-			pause := time.Duration(3000) * time.Millisecond
-			time.Sleep(pause)
-
-			ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
-			if err != nil {
-				return errors.Wrap(err, "logging in")
-			}
-
-			environment, err := c.getEnvironment(ctx)
-			if err != nil {
-				return errors.Wrap(err, "getting environment")
-			}
-
-			state, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
-			if err != nil {
-				return errors.Wrap(err, "getting state")
-			}
-
-			if strings.ToLower(state) != EnvStateRunning {
-				initialized = true
-			}
-
-			if strings.ToLower(state) == EnvStateRunning && initialized {
-				datatypeID, err := c.GetDatatransferDataTypeID(ctx, datatype)
-				if err != nil {
-					return errors.Wrap(err, "getting data type id")
-				}
-
-				res, _, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasGetCollection(ctx).
-					Environment(c.getRcContext(ctx).Environment).
-					DataTransferDataType(datatypeID).
-					OrderCreatedAt("desc").
-					Execute()
-				if err != nil {
-					result = fmt.Sprintf("Error getting exported data: %s", err)
-				} else {
-					if len(res) < 1 {
-						result = "No exported data found"
-					}
-
-					result = fmt.Sprintf("Exported data: %s", res[0].GetUrl())
-				}
-
-				p.Send(ui.ResultMsg{Ready: true})
-
-				return nil
-			}
-
-			p.Send(ui.ResultMsg{Msg: fmt.Sprintf("Environment status: %s", state)})
-		}
-	})
+	return c.finishExport(ctx, exported, c.GetBool("export_db_download"), c.GetString("export_db_output"),
+		profileName, profile)
+}
 
-	if _, err := p.Run(); err != nil {
-		log.Errorf("Error running program: %s", err)
-		os.Exit(1)
+func (c *EnvClient) RunCmdEnvExportMedia(cmd *cobra.Command, args []string) error {
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
 	}
 
-	if err := g.Wait(); err != nil {
-		log.Errorf("Error waiting for program: %s", err)
-		os.Exit(1)
+	exported, err := c.exportMedia(ctx)
+	if err != nil {
+		return err
 	}
 
-	log.Infof("Export finished: %s", result)
-
-	return nil
+	return c.finishExport(ctx, exported, c.GetBool("export_media_download"), c.GetString("export_media_output"),
+		"", nil)
 }
 
-func (c *EnvClient) RunCmdEnvExportMedia(cmd *cobra.Command, args []string) error {
-	const datatype = "Media"
+// exportDatabase exports the database of the environment and returns the export once it is finished.
+func (c *EnvClient) exportDatabase(ctx context.Context, stripDatabase bool) (*rewardcloud.ExportedData, error) {
+	post := rewardcloud.EnvironmentEnvironmentInput{
+		IsStripDatabase: *rewardcloud.NewNullableBool(rewardcloud.PtrBool(stripDatabase)),
+	}
 
-	ctx, err := c.prepareContext(context.Background())
+	_, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdexportDatabasePut(
+		ctx, c.getRcContext(ctx).Environment).EnvironmentEnvironmentInput(post).Execute()
 	if err != nil {
-		return errors.Wrap(err, "preparing context")
+		return nil, errors.Wrap(err, "exporting database")
 	}
 
+	return c.waitForExport(ctx, DataTypeDatabase)
+}
+
+// exportMedia exports the media files of the environment and returns the export once it is finished.
+func (c *EnvClient) exportMedia(ctx context.Context) (*rewardcloud.ExportedData, error) {
 	post := rewardcloud.EnvironmentEnvironmentInput{}
 
-	_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdexportMediaPut(
+	_, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdexportMediaPut(
 		ctx, c.getRcContext(ctx).Environment).EnvironmentEnvironmentInput(post).Execute()
 	if err != nil {
-		return errors.Wrap(err, "exporting media")
+		return nil, errors.Wrap(err, "exporting media")
 	}
 
-	p := tea.NewProgram(ui.NewModel(fmt.Sprintf("Exporting %s...", datatype)))
-	result := ""
-
-	var g errgroup.Group
-	g.Go(func() error {
-		// Initial delay
-		initialized := false
-		for {
-			pause := time.Duration(3000) * time.Millisecond
-			time.Sleep(pause)
-
-			ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
-			if err != nil {
-				return errors.Wrap(err, "logging in")
-			}
-
-			environment, err := c.getEnvironment(ctx)
-			if err != nil {
-				return errors.Wrap(err, "getting environment")
-			}
-
-			state, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
-			if err != nil {
-				return errors.Wrap(err, "getting state")
-			}
-
-			if strings.ToLower(state) != EnvStateRunning {
-				initialized = true
-			}
-
-			if strings.ToLower(state) == EnvStateRunning && initialized {
-				datatypeID, err := c.GetDatatransferDataTypeID(ctx, datatype)
-				if err != nil {
-					return errors.Wrap(err, "getting data type id")
-				}
-
-				res, _, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasGetCollection(ctx).
-					Environment(c.getRcContext(ctx).Environment).
-					DataTransferDataType(datatypeID).
-					OrderCreatedAt("desc").
-					Execute()
-				if err != nil {
-					result = fmt.Sprintf("Error getting exported data: %s", err)
-				} else {
-					if len(res) < 1 {
-						result = "No exported data found"
-					}
-
-					result = fmt.Sprintf("Exported data: %s", res[0].GetUrl())
-				}
-
-				p.Send(ui.ResultMsg{Ready: true})
-
-				return nil
-			}
-
-			p.Send(ui.ResultMsg{Msg: fmt.Sprintf("Environment status: %s", state)})
-		}
-	})
+	return c.waitForExport(ctx, DataTypeMedia)
+}
 
-	if _, err := p.Run(); err != nil {
-		log.Errorf("Error running program: %s", err)
-		os.Exit(1)
+// waitForExport waits for the running export to finish and returns the latest export of the data type.
+func (c *EnvClient) waitForExport(ctx context.Context, datatype string) (*rewardcloud.ExportedData, error) {
+	err := c.trackEnvironmentOperation(ctx, c.getRcContext(ctx).Environment,
+		fmt.Sprintf("Exporting %s...", datatype), EnvStateRunning)
+	if err != nil {
+		return nil, errors.Wrapf(err, "exporting %s", strings.ToLower(datatype))
 	}
 
-	if err := g.Wait(); err != nil {
-		log.Errorf("Error waiting for program: %s", err)
-		os.Exit(1)
+	ctx, err = NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "logging in")
 	}
 
-	log.Infof("Export finished: %s", result)
+	exported, err := c.latestExportedData(ctx, c.getRcContext(ctx).Environment, datatype)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting exported data")
+	}
 
-	return nil
+	return exported, nil
+}
+
+// finishExport prints the url of the exported data and downloads it if requested. If a strip profile is set the
+// export is always downloaded and the profile is applied to the downloaded dump.
+func (c *EnvClient) finishExport(
+	ctx context.Context,
+	exported *rewardcloud.ExportedData,
+	download bool,
+	output, profileName string,
+	profile *strip.Profile,
+) error {
+	log.Infof("Export finished: %s", exported.GetUrl())
+
+	if !download && output == "" && profile == nil {
+		return nil
+	}
+
+	file, err := c.downloadExportedData(ctx, exported, output)
+	if err != nil {
+		return err
+	}
+
+	if profile == nil {
+		return nil
+	}
+
+	return stripDump(ctx, file, profileName, profile)
 }
 
 func (c *EnvClient) GetDatatransferDataTypeID(ctx context.Context, s string) (string, error) {
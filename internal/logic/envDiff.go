@@ -0,0 +1,397 @@
+package logic
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/jedib0t/go-pretty/v6/text"
+	"github.com/pkg/errors"
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+const (
+	DiffFormatUnified    = "unified"
+	DiffFormatSideBySide = "side-by-side"
+)
+
+// volatileFields are the fields which differ between any two environments and are ignored by default.
+var volatileFields = []string{
+	"id", "uuid", "createdAt", "updatedAt", "createdBy", "updatedBy",
+	"environmentAccess", "exportedData", "importedData", "templateIri",
+}
+
+func (c *EnvClient) RunCmdEnvDiff(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_diff_output")
+	if err := checkOutputFormat(format,
+		DiffFormatUnified, DiffFormatSideBySide, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	reveal, err := c.confirmReveal("env_diff_reveal", "env_diff_yes")
+	if err != nil {
+		return err
+	}
+
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "logging in")
+	}
+
+	conf, err := c.ReadConfig()
+	if err != nil {
+		return errors.Wrap(err, "reading config")
+	}
+
+	names := args
+	if len(names) == 1 {
+		names = []string{conf.CurrentContext, args[0]}
+	}
+
+	ignored := c.GetStringSlice("env_diff_ignore")
+	if !c.GetBool("env_diff_include_volatile") {
+		ignored = append(ignored, volatileFields...)
+	}
+
+	snapshots := make([]map[string]string, len(names))
+
+	for i, name := range names {
+		rcContext, err := getRcContextByName(conf, name)
+		if err != nil {
+			return err
+		}
+
+		snapshots[i], err = c.environmentSnapshot(context.WithValue(ctx, config.ContextKey{}, rcContext), reveal)
+		if err != nil {
+			return errors.Wrapf(err, "getting environment of context %s", name)
+		}
+
+		removeIgnoredFields(snapshots[i], ignored)
+	}
+
+	diff := diffValues(snapshots[0], snapshots[1])
+
+	switch strings.ToLower(format) {
+	case OutputFormatJSON, OutputFormatYAML:
+		return printStructured(format, diff)
+	case DiffFormatSideBySide:
+		printSideBySideDiff(diff, names[0], names[1], c.useColors())
+	default:
+		if err := printUnifiedDiff(snapshots[0], snapshots[1], names[0], names[1], c.useColors()); err != nil {
+			return err
+		}
+	}
+
+	if len(diff) == 0 {
+		fmt.Printf("No differences between %s and %s\n", names[0], names[1])
+	}
+
+	return nil
+}
+
+// environmentSnapshot returns the configuration of the environment of the context as flat field paths and values.
+// References to other resources are replaced by their names, the project type version, the cluster, the access
+// endpoints and the environment variables are included.
+func (c *Client) environmentSnapshot(ctx context.Context, reveal bool) (map[string]string, error) {
+	env, err := c.getEnvironment(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// The embedded variables do not contain the encryption flag, they are listed separately below.
+	env.EnvVar = nil
+
+	snapshot := make(map[string]string)
+	if err := flattenJSON(snapshot, "environment", env); err != nil {
+		return nil, err
+	}
+
+	cache := newNameCache()
+
+	state, err := cache.get(env.GetState(), func(id string) (string, error) { return c.getStateNameByID(ctx, id) })
+	if err != nil {
+		return nil, errors.Wrap(err, "getting state")
+	}
+
+	snapshot["environment.state"] = state
+
+	project, err := c.getProject(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot["environment.project"] = project.GetName()
+
+	projectTypeVersion, _, err := c.RewardCloud.ProjectTypeVersionApi.ApiProjectTypeVersionsIdGet(
+		ctx, GetIDFromPath(project.GetProjectTypeVersion())).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting project type version")
+	}
+
+	projectType, err := c.getProjectType(ctx, project)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot["projectType.name"] = projectType.GetName()
+	snapshot["projectType.version"] = projectTypeVersion.GetVersion()
+
+	if env.GetCluster() != "" {
+		cluster, _, err := c.RewardCloud.ClusterApi.ApiClustersIdGet(ctx, GetIDFromPath(env.GetCluster())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting cluster")
+		}
+
+		snapshot["environment.cluster"] = cluster.GetName()
+		snapshot["cluster.server"] = cluster.GetClusterServer()
+		snapshot["cluster.provider"] = cluster.GetProvider()
+	}
+
+	endpoints, err := c.getAccessEndpoints(ctx, env)
+	if err != nil {
+		return nil, err
+	}
+
+	for name, url := range endpoints {
+		snapshot["access."+name] = url
+	}
+
+	vars, err := c.listEnvironmentEnvVars(ctx, strconv.FormatInt(int64(env.GetId()), 10))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range envVarOutputs(vars, reveal) {
+		snapshot["envVar."+v.Key] = v.Value
+	}
+
+	return snapshot, nil
+}
+
+// getAccessEndpoints returns the URLs of the access endpoints of the environment by name.
+func (c *Client) getAccessEndpoints(
+	ctx context.Context, env *rewardcloud.EnvironmentEnvironmentOutput,
+) (map[string]string, error) {
+	endpoints := make(map[string]string)
+
+	if env.GetEnvironmentAccess() == "" {
+		return endpoints, nil
+	}
+
+	access, _, err := c.RewardCloud.EnvironmentAccessApi.ApiEnvironmentAccessesIdGet(
+		ctx, GetIDFromPath(env.GetEnvironmentAccess())).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting accesses")
+	}
+
+	if access.GetFrontend() != "" {
+		frontend, _, err := c.RewardCloud.EnvironmentAccessFrontendApi.ApiEnvironmentAccessFrontendsIdGet(
+			ctx, GetIDFromPath(access.GetFrontend())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting frontend")
+		}
+
+		endpoints["frontend"] = frontend.GetUrl()
+	}
+
+	if access.GetBackend() != "" {
+		backend, _, err := c.RewardCloud.EnvironmentAccessBackendApi.ApiEnvironmentAccessBackendsIdGet(
+			ctx, GetIDFromPath(access.GetBackend())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting backend")
+		}
+
+		endpoints["backend"] = backend.GetUrl()
+	}
+
+	if access.GetDevTools() != "" {
+		devtools, _, err := c.RewardCloud.EnvironmentAccessDevToolsApi.ApiEnvironmentAccessDevToolsIdGet(
+			ctx, GetIDFromPath(access.GetDevTools())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting devtools")
+		}
+
+		endpoints["devtools"] = devtools.GetUrl()
+	}
+
+	if access.GetMailhog() != "" {
+		mailhog, _, err := c.RewardCloud.EnvironmentAccessMailhogApi.ApiEnvironmentAccessMailhogsIdGet(
+			ctx, GetIDFromPath(access.GetMailhog())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting mailhog")
+		}
+
+		endpoints["mailhog"] = mailhog.GetUrl()
+	}
+
+	if access.GetDatabase() != "" {
+		database, _, err := c.RewardCloud.EnvironmentAccessDatabaseApi.ApiEnvironmentAccessDatabasesIdGet(
+			ctx, GetIDFromPath(access.GetDatabase())).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting database")
+		}
+
+		endpoints["database"] = database.GetUrl()
+	}
+
+	return endpoints, nil
+}
+
+// getRcContextByName returns the context with the given name from the config.
+func getRcContextByName(conf *config.Config, name string) (*config.RcContext, error) {
+	for _, rcContext := range conf.Contexts {
+		if rcContext.Name == name {
+			return rcContext, nil
+		}
+	}
+
+	return nil, errors.Errorf("cannot find context %s", name)
+}
+
+// flattenJSON adds the fields of v to out as dot separated paths (list items are indexed, e.g. a.b[0].c).
+func flattenJSON(out map[string]string, prefix string, v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return errors.Wrap(err, "marshalling")
+	}
+
+	var generic interface{}
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return errors.Wrap(err, "unmarshalling")
+	}
+
+	flatten(out, prefix, generic)
+
+	return nil
+}
+
+func flatten(out map[string]string, prefix string, v interface{}) {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			flatten(out, prefix+"."+key, value)
+		}
+	case []interface{}:
+		for i, value := range v {
+			flatten(out, fmt.Sprintf("%s[%d]", prefix, i), value)
+		}
+	case nil:
+		out[prefix] = "null"
+	case string:
+		out[prefix] = v
+	default:
+		out[prefix] = fmt.Sprint(v)
+	}
+}
+
+// removeIgnoredFields removes the fields which have an ignored field name as one of their path elements or which
+// are below an ignored path. List indexes are not part of the path elements, so ignoring a list or an object
+// ignores all of its items and fields.
+func removeIgnoredFields(snapshot map[string]string, ignored []string) {
+	for path := range snapshot {
+		for _, field := range ignored {
+			if isIgnoredField(path, field) {
+				delete(snapshot, path)
+
+				break
+			}
+		}
+	}
+}
+
+func isIgnoredField(path, field string) bool {
+	if field == path || strings.HasPrefix(path, field+".") || strings.HasPrefix(path, field+"[") {
+		return true
+	}
+
+	for _, element := range strings.Split(path, ".") {
+		if i := strings.Index(element, "["); i >= 0 {
+			element = element[:i]
+		}
+
+		if element == field {
+			return true
+		}
+	}
+
+	return false
+}
+
+// printUnifiedDiff prints the unified diff of the snapshots as "path: value" lines.
+func printUnifiedDiff(a, b map[string]string, nameA, nameB string, colors bool) error {
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        snapshotLines(a),
+		B:        snapshotLines(b),
+		FromFile: nameA,
+		ToFile:   nameB,
+		Context:  3,
+	})
+	if err != nil {
+		return errors.Wrap(err, "creating diff")
+	}
+
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		switch {
+		case !colors || line == "":
+		case strings.HasPrefix(line, "+++"), strings.HasPrefix(line, "---"):
+			line = text.Bold.Sprint(line)
+		case strings.HasPrefix(line, "@@"):
+			line = text.FgCyan.Sprint(line)
+		case strings.HasPrefix(line, "+"):
+			line = text.FgGreen.Sprint(line)
+		case strings.HasPrefix(line, "-"):
+			line = text.FgRed.Sprint(line)
+		}
+
+		fmt.Print(line)
+	}
+
+	return nil
+}
+
+// printSideBySideDiff prints the differing fields in a table with the values of both environments.
+func printSideBySideDiff(diff []ValueDiff, nameA, nameB string, colors bool) {
+	if len(diff) == 0 {
+		return
+	}
+
+	t := NewTableWriter(WithTableWidthMax(160))
+	t.AppendHeader(table.Row{"Field", nameA, nameB})
+
+	for _, d := range diff {
+		a, b := valueOrDash(d.Value), valueOrDash(d.Other)
+		if colors {
+			a, b = text.FgRed.Sprint(a), text.FgGreen.Sprint(b)
+		}
+
+		t.AppendRow(table.Row{d.Key, a, b})
+	}
+
+	t.Render()
+}
+
+func snapshotLines(snapshot map[string]string) []string {
+	lines := make([]string, 0, len(snapshot))
+	for path, value := range snapshot {
+		lines = append(lines, fmt.Sprintf("%s: %s\n", path, value))
+	}
+
+	sort.Strings(lines)
+
+	return lines
+}
+
+// useColors returns true if the output is a terminal and colors are not disabled.
+func (c *Client) useColors() bool {
+	return !c.GetBool("disable_colors") && term.IsTerminal(int(os.Stdout.Fd()))
+}
@@ -0,0 +1,115 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type EnvDiffTestSuite struct {
+	suite.Suite
+}
+
+func TestEnvDiffTestSuite(t *testing.T) {
+	suite.Run(t, new(EnvDiffTestSuite))
+}
+
+func (suite *EnvDiffTestSuite) TestFlatten() {
+	got := map[string]string{}
+	flatten(got, "environment", map[string]interface{}{
+		"name":    "staging",
+		"cpu":     float64(2),
+		"enabled": true,
+		"cluster": nil,
+		"environmentComponent": map[string]interface{}{
+			"php": map[string]interface{}{"version": "8.2"},
+		},
+		"envVars": []interface{}{
+			map[string]interface{}{"key": "APP_ENV"},
+			"plain",
+		},
+	})
+
+	suite.Equal(map[string]string{
+		"environment.name":                             "staging",
+		"environment.cpu":                              "2",
+		"environment.enabled":                          "true",
+		"environment.cluster":                          "null",
+		"environment.environmentComponent.php.version": "8.2",
+		"environment.envVars[0].key":                   "APP_ENV",
+		"environment.envVars[1]":                       "plain",
+	}, got)
+}
+
+func (suite *EnvDiffTestSuite) TestRemoveIgnoredFields() {
+	snapshot := func() map[string]string {
+		return map[string]string{
+			"environment.id":   "1",
+			"environment.name": "staging",
+			"environment.cpu":  "2",
+			"environment.environmentComponent.php.version": "8.2",
+			"environment.environmentComponent.php.id":      "3",
+			"environment.exportedData[0]":                  "/api/exported_data/1",
+			"environment.environmentAccess[0].user":        "admin",
+			"environment.environmentAccess[1].user":        "deploy",
+			"endpoints.frontend":                           "https://staging.example.org",
+		}
+	}
+
+	tests := []struct {
+		name    string
+		ignored []string
+		want    []string
+	}{
+		{
+			name:    "field name",
+			ignored: []string{"id", "cpu"},
+			want: []string{
+				"environment.name", "environment.environmentComponent.php.version", "environment.exportedData[0]",
+				"environment.environmentAccess[0].user", "environment.environmentAccess[1].user",
+				"endpoints.frontend",
+			},
+		},
+		{
+			name:    "lists",
+			ignored: []string{"exportedData", "environmentAccess"},
+			want: []string{
+				"environment.id", "environment.name", "environment.cpu",
+				"environment.environmentComponent.php.version", "environment.environmentComponent.php.id",
+				"endpoints.frontend",
+			},
+		},
+		{
+			name:    "path",
+			ignored: []string{"environment.environmentComponent", "endpoints"},
+			want: []string{
+				"environment.id", "environment.name", "environment.cpu", "environment.exportedData[0]",
+				"environment.environmentAccess[0].user", "environment.environmentAccess[1].user",
+			},
+		},
+		{
+			name:    "prefix of a field name",
+			ignored: []string{"environment.environment", "env"},
+			want: []string{
+				"environment.id", "environment.name", "environment.cpu",
+				"environment.environmentComponent.php.version", "environment.environmentComponent.php.id",
+				"environment.exportedData[0]", "environment.environmentAccess[0].user",
+				"environment.environmentAccess[1].user", "endpoints.frontend",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			got := snapshot()
+			removeIgnoredFields(got, tt.ignored)
+
+			keys := make([]string, 0, len(got))
+			for key := range got {
+				keys = append(keys, key)
+			}
+
+			suite.ElementsMatch(tt.want, keys)
+		})
+	}
+}
@@ -0,0 +1,399 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	btable "github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/ui"
+)
+
+const environmentsPageSize = 100
+
+// EnvironmentOutput is the machine-readable representation of an environment.
+type EnvironmentOutput struct {
+	ID       int32  `json:"id" yaml:"id"`
+	Name     string `json:"name" yaml:"name"`
+	CodeName string `json:"codeName" yaml:"codeName"`
+	State    string `json:"state" yaml:"state"`
+	Cluster  string `json:"cluster" yaml:"cluster"`
+	// LastDeployedAt is the last modification time of the environment. The API does not expose a separate
+	// deployment timestamp and every deployment updates the environment.
+	LastDeployedAt *time.Time `json:"lastDeployedAt" yaml:"lastDeployedAt"`
+}
+
+func (c *EnvClient) RunCmdEnvList(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_list_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	ctx, projectID, err := c.prepareProjectContext(cmd.Context(), c.GetString("env_list_project"))
+	if err != nil {
+		return err
+	}
+
+	environments, err := c.listEnvironments(ctx, projectID, newNameCache())
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, environments)
+	}
+
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"ID", "Name", "Codename", "State", "Cluster", "Last Deploy"})
+
+	for _, e := range environments {
+		t.AppendRow(table.Row{e.ID, e.Name, e.CodeName, e.State, e.Cluster, formatTime(e.LastDeployedAt)})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvStatus(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_status_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	interval := c.GetDuration("env_status_interval")
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	ctx, projectID, err := c.prepareProjectContext(cmd.Context(), c.GetString("env_status_project"))
+	if err != nil {
+		return err
+	}
+
+	cache := newNameCache()
+
+	if !c.GetBool("env_status_watch") {
+		environments, err := c.listEnvironments(ctx, projectID, cache)
+		if err != nil {
+			return err
+		}
+
+		if isStructuredOutput(format) {
+			return printStructured(format, environments)
+		}
+
+		t := NewTableWriter()
+		t.AppendHeader(table.Row{"Name", "State", "Last Deploy"})
+
+		for _, e := range environments {
+			t.AppendRow(table.Row{e.Name, e.State, formatTime(e.LastDeployedAt)})
+		}
+
+		t.Render()
+
+		return nil
+	}
+
+	if isStructuredOutput(format) {
+		return c.watchEnvironmentsStructured(ctx, projectID, cache, format, interval)
+	}
+
+	return c.watchEnvironments(ctx, projectID, cache, interval)
+}
+
+// watchEnvironments renders the environments of the project in a table which is refreshed periodically. The token
+// is checked on every refresh, as it expires while watching.
+func (c *EnvClient) watchEnvironments(ctx context.Context, projectID string, cache *nameCache, interval time.Duration,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(ui.NewTableModel("Environments", []btable.Column{
+		{Title: "Name", Width: 24},
+		{Title: "Codename", Width: 16},
+		{Title: "State", Width: 14},
+		{Title: "Cluster", Width: 16},
+		{Title: "Last Deploy", Width: 19},
+	}))
+
+	var g errgroup.Group
+	g.Go(func() error {
+		var previous []EnvironmentOutput
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			environments, err := c.listEnvironmentsWithLogin(ctx, projectID, cache)
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			if err != nil {
+				p.Send(ui.TableMsg{Err: err})
+			} else {
+				rows := make([]btable.Row, 0, len(environments))
+				for _, e := range environments {
+					rows = append(rows, btable.Row{e.Name, e.CodeName, e.State, e.Cluster, formatTime(e.LastDeployedAt)})
+				}
+
+				p.Send(ui.TableMsg{Rows: rows, Events: stateChanges(previous, environments)})
+
+				previous = environments
+			}
+
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-ticker.C:
+			}
+		}
+	})
+
+	_, err := p.Run()
+
+	cancel()
+	_ = g.Wait()
+
+	return errors.Wrap(err, "running status ui")
+}
+
+// watchEnvironmentsStructured prints the environments of the project every time they change until the context is
+// canceled.
+func (c *EnvClient) watchEnvironmentsStructured(
+	ctx context.Context, projectID string, cache *nameCache, format string, interval time.Duration,
+) error {
+	var previous []EnvironmentOutput
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		environments, err := c.listEnvironmentsWithLogin(ctx, projectID, cache)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if !reflect.DeepEqual(previous, environments) {
+			if format == OutputFormatYAML && previous != nil {
+				fmt.Println("---")
+			}
+
+			if err := writeStructured(os.Stdout, format, environments); err != nil {
+				return err
+			}
+
+			previous = environments
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// listEnvironmentsWithLogin checks the token before it lists the environments of the project.
+func (c *EnvClient) listEnvironmentsWithLogin(ctx context.Context, projectID string, cache *nameCache,
+) ([]EnvironmentOutput, error) {
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "logging in")
+	}
+
+	return c.listEnvironments(ctx, projectID, cache)
+}
+
+// stateChanges returns a line for every environment whose state changed since the previous refresh.
+func stateChanges(previous, current []EnvironmentOutput) []string {
+	if previous == nil {
+		return nil
+	}
+
+	states := make(map[int32]string, len(previous))
+	for _, e := range previous {
+		states[e.ID] = e.State
+	}
+
+	var events []string
+
+	now := time.Now().Format("15:04:05")
+
+	for _, e := range current {
+		old, ok := states[e.ID]
+
+		switch {
+		case !ok:
+			events = append(events, fmt.Sprintf("%s %s: created (%s)", now, e.Name, e.State))
+		case old != e.State:
+			events = append(events, fmt.Sprintf("%s %s: %s -> %s", now, e.Name, old, e.State))
+		}
+	}
+
+	return events
+}
+
+// prepareProjectContext logs in and returns the id of the project given by ref (id, codename or name). Without a
+// ref the project of the current context is used, so a context is only needed if no project is given.
+func (c *Client) prepareProjectContext(ctx context.Context, ref string) (context.Context, string, error) {
+	if ref == "" {
+		ctx, err := c.prepareContext(ctx)
+		if err != nil {
+			return nil, "", errors.Wrap(err, "preparing context")
+		}
+
+		return ctx, c.getRcContext(ctx).Project, nil
+	}
+
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+	if err != nil {
+		return nil, "", errors.Wrap(err, "logging in")
+	}
+
+	projectID, err := c.resolveProjectID(ctx, ref)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return ctx, projectID, nil
+}
+
+// resolveProjectID returns the id of the project referenced by ref (id, codename or name). If ref is empty the
+// project of the current context is used.
+func (c *Client) resolveProjectID(ctx context.Context, ref string) (string, error) {
+	if ref == "" {
+		return c.getRcContext(ctx).Project, nil
+	}
+
+	if _, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		return ref, nil
+	}
+
+	for page := int32(1); ; page++ {
+		projects, _, err := c.RewardCloud.ProjectApi.ApiProjectsGetCollection(ctx).
+			Page(page).
+			ItemsPerPage(environmentsPageSize).
+			Execute()
+		if err != nil {
+			return "", errors.Wrap(err, "getting projects")
+		}
+
+		for _, p := range projects {
+			if p.GetCodeName() == ref || strings.EqualFold(p.GetName(), ref) {
+				return strconv.FormatInt(int64(p.GetId()), 10), nil
+			}
+		}
+
+		if len(projects) < environmentsPageSize {
+			return "", errors.Errorf("cannot find project %s", ref)
+		}
+	}
+}
+
+// listEnvironments returns the environments of the project with their state and cluster names.
+func (c *Client) listEnvironments(ctx context.Context, projectID string, cache *nameCache,
+) ([]EnvironmentOutput, error) {
+	var environments []EnvironmentOutput
+
+	for page := int32(1); ; page++ {
+		res, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsGetCollection(ctx).
+			Project(projectID).
+			Page(page).
+			ItemsPerPage(environmentsPageSize).
+			Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting environments")
+		}
+
+		for i := range res {
+			e, err := c.environmentOutput(ctx, &res[i], cache)
+			if err != nil {
+				return nil, err
+			}
+
+			environments = append(environments, e)
+		}
+
+		if len(res) < environmentsPageSize {
+			return environments, nil
+		}
+	}
+}
+
+func (c *Client) environmentOutput(
+	ctx context.Context, e *rewardcloud.EnvironmentEnvironmentOutput, cache *nameCache,
+) (EnvironmentOutput, error) {
+	state, err := cache.get(e.GetState(), func(id string) (string, error) {
+		return c.getStateNameByID(ctx, id)
+	})
+	if err != nil {
+		return EnvironmentOutput{}, errors.Wrapf(err, "getting state of %s", e.GetName())
+	}
+
+	cluster, err := cache.get(e.GetCluster(), func(id string) (string, error) {
+		cluster, _, err := c.RewardCloud.ClusterApi.ApiClustersIdGet(ctx, id).Execute()
+		if err != nil {
+			return "", errors.Wrap(err, "getting cluster")
+		}
+
+		return cluster.GetName(), nil
+	})
+	if err != nil {
+		return EnvironmentOutput{}, errors.Wrapf(err, "getting cluster of %s", e.GetName())
+	}
+
+	return EnvironmentOutput{
+		ID:             e.GetId(),
+		Name:           e.GetName(),
+		CodeName:       e.GetCodeName(),
+		State:          state,
+		Cluster:        cluster,
+		LastDeployedAt: e.UpdatedAt,
+	}, nil
+}
+
+// nameCache caches the names of the resources referenced by IRIs (e.g. states and clusters).
+type nameCache struct {
+	names map[string]string
+}
+
+func newNameCache() *nameCache {
+	return &nameCache{names: make(map[string]string)}
+}
+
+// get returns the cached name of the resource or looks it up using fetch. Empty IRIs return "-".
+func (n *nameCache) get(iri string, fetch func(id string) (string, error)) (string, error) {
+	if iri == "" {
+		return "-", nil
+	}
+
+	if name, ok := n.names[iri]; ok {
+		return name, nil
+	}
+
+	name, err := fetch(GetIDFromPath(iri))
+	if err != nil {
+		return "", err
+	}
+
+	n.names[iri] = name
+
+	return name, nil
+}
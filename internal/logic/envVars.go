@@ -0,0 +1,595 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+const envVarsPageSize = 100
+
+// listEnvironmentEnvVars returns the environment variables of the environment.
+func (c *Client) listEnvironmentEnvVars(ctx context.Context, envID string) ([]rewardcloud.EnvironmentEnvVar, error) {
+	var vars []rewardcloud.EnvironmentEnvVar
+
+	for page := int32(1); ; page++ {
+		res, _, err := c.RewardCloud.EnvironmentEnvVarApi.ApiEnvironmentEnvVarsGetCollection(ctx).
+			Environment(envID).
+			Page(page).
+			ItemsPerPage(envVarsPageSize).
+			Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting environment variables")
+		}
+
+		vars = append(vars, res...)
+
+		if len(res) < envVarsPageSize {
+			return vars, nil
+		}
+	}
+}
+
+// overrideEnvironmentEnvVar sets the environment variable of the environment until the returned function is called,
+// which restores the previous value or removes the variable if it was not set.
+func (c *Client) overrideEnvironmentEnvVar(ctx context.Context, envID, key, value string) (
+	func(ctx context.Context) error, error,
+) {
+	vars, err := c.listEnvironmentEnvVars(ctx, envID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, v := range vars {
+		if v.GetKey() != key {
+			continue
+		}
+
+		if err := c.updateEnvironmentEnvVar(ctx, v.GetId(), key, value, v.GetIsEncrypted()); err != nil {
+			return nil, err
+		}
+
+		previous := envVarValue(v)
+
+		return func(ctx context.Context) error {
+			return c.updateEnvironmentEnvVar(ctx, v.GetId(), key, previous, v.GetIsEncrypted())
+		}, nil
+	}
+
+	id, err := c.createEnvironmentEnvVar(ctx, envID, key, value, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return func(ctx context.Context) error {
+		return c.deleteEnvironmentEnvVar(ctx, id, key)
+	}, nil
+}
+
+// createEnvironmentEnvVar creates the environment variable of the environment and returns its id.
+func (c *Client) createEnvironmentEnvVar(ctx context.Context, envID, key, value string, encrypted bool) (int32, error) {
+	var created struct {
+		ID int32 `json:"id"`
+	}
+
+	// The generated SDK cannot reference the environment by its IRI, so the variable is created using a raw request.
+	err := c.doAPIRequest(ctx, http.MethodPost, "/api/environment_env_vars", map[string]interface{}{
+		"environment": fmt.Sprintf("/api/environments/%s", envID),
+		"key":         key,
+		"value":       value,
+		"isEncrypted": encrypted,
+	}, &created)
+
+	return created.ID, errors.Wrapf(err, "creating environment variable %s", key)
+}
+
+// updateEnvironmentEnvVar updates the value of the environment variable. Other fields of the variable are kept.
+func (c *Client) updateEnvironmentEnvVar(ctx context.Context, id int32, key, value string, encrypted bool) error {
+	patch := rewardcloud.EnvironmentEnvVar{
+		Value:       *rewardcloud.NewNullableString(rewardcloud.PtrString(value)),
+		IsEncrypted: *rewardcloud.NewNullableBool(rewardcloud.PtrBool(encrypted)),
+	}
+
+	_, _, err := c.RewardCloud.EnvironmentEnvVarApi.ApiEnvironmentEnvVarsIdPatch(ctx, fmt.Sprintf("%d", id)).
+		EnvironmentEnvVar(patch).
+		Execute()
+
+	return errors.Wrapf(err, "updating environment variable %s", key)
+}
+
+// deleteEnvironmentEnvVar deletes the environment variable.
+func (c *Client) deleteEnvironmentEnvVar(ctx context.Context, id int32, key string) error {
+	_, err := c.RewardCloud.EnvironmentEnvVarApi.ApiEnvironmentEnvVarsIdDelete(ctx, fmt.Sprintf("%d", id)).Execute()
+
+	return errors.Wrapf(err, "deleting environment variable %s", key)
+}
+
+const maskedValue = "********"
+
+// secretKeyPattern matches the keys of the variables which are masked even if they are not encrypted. Only whole
+// segments of the key match, so e.g. AUTHOR or BYPASS_CACHE are not masked.
+var secretKeyPattern = regexp.MustCompile(
+	`(?i)(^|_)(pass(word|wd)?|pwd|secrets?|tokens?|(api)?keys?|credentials?|private|auth)(_|$)`,
+)
+
+// EnvVarOutput is the machine-readable representation of an environment variable.
+type EnvVarOutput struct {
+	Key    string `json:"key" yaml:"key"`
+	Value  string `json:"value" yaml:"value"`
+	Secret bool   `json:"secret" yaml:"secret"`
+}
+
+func (c *EnvClient) RunCmdEnvVarsList(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_vars_list_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	reveal, err := c.confirmReveal("env_vars_list_reveal", "env_vars_list_yes")
+	if err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	vars, err := c.listEnvironmentEnvVars(ctx, c.getRcContext(ctx).Environment)
+	if err != nil {
+		return err
+	}
+
+	out := envVarOutputs(vars, reveal)
+
+	if isStructuredOutput(format) {
+		return printStructured(format, out)
+	}
+
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"Key", "Value", "Secret"})
+
+	for _, v := range out {
+		t.AppendRow(table.Row{v.Key, v.Value, v.Secret})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvVarsGet(cmd *cobra.Command, args []string) error {
+	reveal, err := c.confirmReveal("env_vars_get_reveal", "env_vars_get_yes")
+	if err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	vars, err := c.listEnvironmentEnvVars(ctx, c.getRcContext(ctx).Environment)
+	if err != nil {
+		return err
+	}
+
+	for _, v := range envVarOutputs(vars, reveal) {
+		if v.Key == args[0] {
+			fmt.Println(v.Value)
+
+			return nil
+		}
+	}
+
+	return errors.Errorf("environment variable %s is not set", args[0])
+}
+
+func (c *EnvClient) RunCmdEnvVarsSet(cmd *cobra.Command, args []string) error {
+	set := make(map[string]string, len(args))
+
+	for _, arg := range args {
+		key, value, ok := strings.Cut(arg, "=")
+		if !ok || key == "" {
+			return errors.Errorf("invalid argument %q, please use KEY=VALUE", arg)
+		}
+
+		set[key] = value
+	}
+
+	return c.updateEnvVars(cmd.Context(), set, nil, "env_vars_set")
+}
+
+func (c *EnvClient) RunCmdEnvVarsUnset(cmd *cobra.Command, args []string) error {
+	return c.updateEnvVars(cmd.Context(), nil, args, "env_vars_unset")
+}
+
+func (c *EnvClient) RunCmdEnvVarsImport(cmd *cobra.Command, args []string) error {
+	f, err := os.Open(args[0])
+	if err != nil {
+		return errors.Wrap(err, "opening file")
+	}
+	defer f.Close()
+
+	set, err := parseDotEnv(f)
+	if err != nil {
+		return errors.Wrapf(err, "parsing %s", args[0])
+	}
+
+	if len(set) == 0 {
+		return errors.Errorf("there are no variables in %s", args[0])
+	}
+
+	return c.updateEnvVars(cmd.Context(), set, nil, "env_vars_import")
+}
+
+func (c *EnvClient) RunCmdEnvVarsExport(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_vars_export_output")
+	if err := checkOutputFormat(format, OutputFormatDotEnv, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	reveal, err := c.confirmReveal("env_vars_export_reveal", "env_vars_export_yes")
+	if err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	vars, err := c.listEnvironmentEnvVars(ctx, c.getRcContext(ctx).Environment)
+	if err != nil {
+		return err
+	}
+
+	out := envVarOutputs(vars, reveal)
+
+	if !reveal {
+		for _, v := range out {
+			if v.Secret {
+				log.Warn("Secret values are masked in the export, use --reveal to export them")
+
+				break
+			}
+		}
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, out)
+	}
+
+	for _, v := range out {
+		fmt.Printf("%s=%s\n", v.Key, quoteDotEnvValue(v.Value))
+	}
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvVarsDiff(cmd *cobra.Command, args []string) error {
+	format := c.GetString("env_vars_diff_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	reveal, err := c.confirmReveal("env_vars_diff_reveal", "env_vars_diff_yes")
+	if err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	env, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	other, err := c.resolveEnvironment(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	vars, err := c.listEnvironmentEnvVars(ctx, strconv.FormatInt(int64(env.GetId()), 10))
+	if err != nil {
+		return err
+	}
+
+	otherVars, err := c.listEnvironmentEnvVars(ctx, strconv.FormatInt(int64(other.GetId()), 10))
+	if err != nil {
+		return err
+	}
+
+	diff := diffValues(envVarValues(envVarOutputs(vars, reveal)), envVarValues(envVarOutputs(otherVars, reveal)))
+
+	if isStructuredOutput(format) {
+		return printStructured(format, diff)
+	}
+
+	if len(diff) == 0 {
+		log.Infof("Environments %s and %s have the same variables", env.GetName(), other.GetName())
+
+		return nil
+	}
+
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"Key", "Status", env.GetName(), other.GetName()})
+
+	for _, d := range diff {
+		t.AppendRow(table.Row{d.Key, d.Status, valueOrDash(d.Value), valueOrDash(d.Other)})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+// updateEnvVars sets and unsets the variables of the environment of the current context. The changes are listed
+// and, after confirmation, applied in a single patch of the environment, so either all or none of them are applied.
+// The flags are read using the keyPrefix of the command.
+func (c *EnvClient) updateEnvVars(ctx context.Context, set map[string]string, unset []string, keyPrefix string) error {
+	ctx, err := c.prepareContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	env, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	envID := strconv.FormatInt(int64(env.GetId()), 10)
+
+	vars, err := c.listEnvironmentEnvVars(ctx, envID)
+	if err != nil {
+		return err
+	}
+
+	existing := make(map[string]bool, len(vars))
+	for _, v := range vars {
+		existing[v.GetKey()] = true
+	}
+
+	for _, key := range unset {
+		if !existing[key] {
+			return errors.Errorf("environment variable %s is not set", key)
+		}
+	}
+
+	secret := c.GetBool(keyPrefix + "_secret")
+	input, changes := mergeEnvVars(vars, set, unset, secret)
+
+	if len(changes) == 0 {
+		log.Info("No changes.")
+
+		return nil
+	}
+
+	fmt.Printf("Changes to environment %s:\n", env.GetName())
+
+	for _, change := range changes {
+		fmt.Println("  " + change.String())
+	}
+
+	if !c.GetBool(keyPrefix + "_yes") {
+		val, err := GetValueFromPrompt(fmt.Sprintf("Apply %d changes? [y/n]", len(changes)))
+		if err != nil {
+			return errors.Wrap(err, "getting confirmation")
+		}
+
+		if !isYes(val) {
+			log.Info("Aborted.")
+
+			return nil
+		}
+	}
+
+	if len(input) == 0 {
+		// The generated SDK omits empty lists, so removing the last variable needs a raw request.
+		err = c.doAPIRequest(ctx, http.MethodPatch, fmt.Sprintf("/api/environments/%s", envID),
+			map[string]interface{}{"envVar": []interface{}{}}, nil)
+	} else {
+		_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdPatch(ctx, envID).
+			EnvironmentEnvironmentInput(rewardcloud.EnvironmentEnvironmentInput{EnvVar: input}).
+			Execute()
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "updating environment variables")
+	}
+
+	log.Infof("%d changes applied to environment %s", len(changes), env.GetName())
+
+	if !c.GetBool(keyPrefix + "_deploy") {
+		log.Info("The changes take effect after the next deployment (use --deploy to deploy now)")
+
+		return nil
+	}
+
+	if err := c.buildAndDeploy(ctx, env); err != nil {
+		return errors.Wrap(err, "building environment")
+	}
+
+	log.Infof("Build and deploy finished")
+
+	return nil
+}
+
+const (
+	envVarAdded   = "+"
+	envVarChanged = "~"
+	envVarRemoved = "-"
+)
+
+// envVarChange is a change of an environment variable.
+type envVarChange struct {
+	Op        string
+	Key       string
+	Value     string
+	Encrypted bool
+}
+
+func (c envVarChange) String() string {
+	return c.Op + " " + c.Key
+}
+
+// mergeEnvVars returns the complete list of variables after setting and unsetting the given ones, and the list of
+// changes. Unchanged variables keep their value as it was entered (e.g. references) and their encryption. New
+// variables are encrypted if secret is set, changed ones keep their encryption.
+func mergeEnvVars(vars []rewardcloud.EnvironmentEnvVar, set map[string]string, unset []string, secret bool,
+) ([]rewardcloud.EnvironmentEnvVarEnvironmentInput, []envVarChange) {
+	removed := make(map[string]bool, len(unset))
+	for _, key := range unset {
+		removed[key] = true
+	}
+
+	var (
+		input   []rewardcloud.EnvironmentEnvVarEnvironmentInput
+		changes []envVarChange
+		seen    = make(map[string]bool, len(vars))
+	)
+
+	for _, v := range vars {
+		key, value, encrypted := v.GetKey(), envVarValue(v), v.GetIsEncrypted()
+		seen[key] = true
+
+		if removed[key] {
+			changes = append(changes, envVarChange{Op: envVarRemoved, Key: key})
+
+			continue
+		}
+
+		if newValue, ok := set[key]; ok && (newValue != value || (secret && !encrypted)) {
+			value, encrypted = newValue, encrypted || secret
+			changes = append(changes, envVarChange{Op: envVarChanged, Key: key, Value: value, Encrypted: encrypted})
+		}
+
+		in := rewardcloud.EnvironmentEnvVarEnvironmentInput{
+			Key:         *rewardcloud.NewNullableString(rewardcloud.PtrString(key)),
+			Value:       *rewardcloud.NewNullableString(rewardcloud.PtrString(value)),
+			IsEncrypted: *rewardcloud.NewNullableBool(rewardcloud.PtrBool(encrypted)),
+		}
+		if v.EnvVarType.IsSet() {
+			in.EnvVarType = v.EnvVarType
+		}
+
+		input = append(input, in)
+	}
+
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		if !seen[key] {
+			keys = append(keys, key)
+		}
+	}
+
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		changes = append(changes, envVarChange{Op: envVarAdded, Key: key, Value: set[key], Encrypted: secret})
+		input = append(input, rewardcloud.EnvironmentEnvVarEnvironmentInput{
+			Key:         *rewardcloud.NewNullableString(rewardcloud.PtrString(key)),
+			Value:       *rewardcloud.NewNullableString(rewardcloud.PtrString(set[key])),
+			IsEncrypted: *rewardcloud.NewNullableBool(rewardcloud.PtrBool(secret)),
+		})
+	}
+
+	return input, changes
+}
+
+// confirmReveal returns true if the secret values should be revealed. Revealing needs confirmation unless the
+// yes flag is set.
+func (c *EnvClient) confirmReveal(revealKey, yesKey string) (bool, error) {
+	if !c.GetBool(revealKey) {
+		return false, nil
+	}
+
+	if c.GetBool(yesKey) {
+		return true, nil
+	}
+
+	val, err := GetValueFromPrompt("Secret values will be shown in plain text. Continue? [y/n]")
+	if err != nil {
+		return false, errors.Wrap(err, "getting confirmation")
+	}
+
+	if !isYes(val) {
+		return false, errors.New("aborted")
+	}
+
+	return true, nil
+}
+
+// envVarOutputs converts the variables to their output representation sorted by key. Secret values are masked
+// unless reveal is set.
+func envVarOutputs(vars []rewardcloud.EnvironmentEnvVar, reveal bool) []EnvVarOutput {
+	out := make([]EnvVarOutput, 0, len(vars))
+
+	for _, v := range vars {
+		o := EnvVarOutput{
+			Key:    v.GetKey(),
+			Value:  envVarValue(v),
+			Secret: v.GetIsEncrypted() || secretKeyPattern.MatchString(v.GetKey()),
+		}
+
+		if o.Secret && !reveal {
+			o.Value = maskedValue
+		}
+
+		out = append(out, o)
+	}
+
+	sort.Slice(out, func(i, j int) bool { return out[i].Key < out[j].Key })
+
+	return out
+}
+
+// envVarValues returns the values of the variables by key.
+func envVarValues(vars []EnvVarOutput) map[string]string {
+	values := make(map[string]string, len(vars))
+	for _, v := range vars {
+		values[v.Key] = v.Value
+	}
+
+	return values
+}
+
+// envVarValue returns the value of the variable as it was entered (e.g. before resolving references).
+func envVarValue(v rewardcloud.EnvironmentEnvVar) string {
+	if v.RawValue.IsSet() && v.GetRawValue() != "" {
+		return v.GetRawValue()
+	}
+
+	return v.GetValue()
+}
+
+// dotEnvEscaper escapes the characters of a double-quoted dotenv value, see parseDotEnv.
+var dotEnvEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `$`, `\$`, "\n", `\n`, "\r", `\r`)
+
+// quoteDotEnvValue quotes the value if it cannot be written as is into a dotenv file. Values are single-quoted if
+// possible, as they are taken literally, otherwise they are double-quoted and escaped.
+func quoteDotEnvValue(value string) string {
+	if value != "" && !strings.ContainsAny(value, " \t#'\"$\\\n\r") {
+		return value
+	}
+
+	if !strings.ContainsAny(value, "'\n\r") {
+		return "'" + value + "'"
+	}
+
+	return `"` + dotEnvEscaper.Replace(value) + `"`
+}
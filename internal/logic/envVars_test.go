@@ -0,0 +1,207 @@
+package logic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/stretchr/testify/suite"
+)
+
+type EnvVarsTestSuite struct {
+	suite.Suite
+}
+
+func TestEnvVarsTestSuite(t *testing.T) {
+	suite.Run(t, new(EnvVarsTestSuite))
+}
+
+func envVar(id int32, key, value, rawValue string, encrypted bool) rewardcloud.EnvironmentEnvVar {
+	v := rewardcloud.EnvironmentEnvVar{
+		Id:          rewardcloud.PtrInt32(id),
+		Key:         *rewardcloud.NewNullableString(rewardcloud.PtrString(key)),
+		Value:       *rewardcloud.NewNullableString(rewardcloud.PtrString(value)),
+		IsEncrypted: *rewardcloud.NewNullableBool(rewardcloud.PtrBool(encrypted)),
+	}
+
+	if rawValue != "" {
+		v.RawValue = *rewardcloud.NewNullableString(rewardcloud.PtrString(rawValue))
+	}
+
+	return v
+}
+
+func (suite *EnvVarsTestSuite) TestMergeEnvVars() {
+	vars := []rewardcloud.EnvironmentEnvVar{
+		envVar(1, "APP_ENV", "staging", "", false),
+		envVar(2, "API_SECRET", "s3cr3t", "", true),
+		envVar(3, "BASE_URL", "https://staging.example.org", "https://${HOST}", false),
+	}
+
+	tests := []struct {
+		name   string
+		set    map[string]string
+		unset  []string
+		secret bool
+		want   []envVarChange
+	}{
+		{
+			name: "unchanged values",
+			set:  map[string]string{"APP_ENV": "staging", "API_SECRET": "s3cr3t", "BASE_URL": "https://${HOST}"},
+			want: nil,
+		},
+		{
+			name: "change",
+			set:  map[string]string{"APP_ENV": "production", "API_SECRET": "s3cr3t"},
+			want: []envVarChange{{Op: envVarChanged, Key: "APP_ENV", Value: "production"}},
+		},
+		{
+			name: "change keeps the encryption",
+			set:  map[string]string{"API_SECRET": "n3w"},
+			want: []envVarChange{{Op: envVarChanged, Key: "API_SECRET", Value: "n3w", Encrypted: true}},
+		},
+		{
+			name:   "encrypt an unchanged value",
+			set:    map[string]string{"APP_ENV": "staging"},
+			secret: true,
+			want:   []envVarChange{{Op: envVarChanged, Key: "APP_ENV", Value: "staging", Encrypted: true}},
+		},
+		{
+			name:   "add and remove",
+			set:    map[string]string{"TOKEN": "t", "DEBUG": "1"},
+			unset:  []string{"BASE_URL"},
+			secret: true,
+			want: []envVarChange{
+				{Op: envVarRemoved, Key: "BASE_URL"},
+				{Op: envVarAdded, Key: "DEBUG", Value: "1", Encrypted: true},
+				{Op: envVarAdded, Key: "TOKEN", Value: "t", Encrypted: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			input, changes := mergeEnvVars(vars, tt.set, tt.unset, tt.secret)
+			suite.Equal(tt.want, changes)
+
+			// The patch contains every variable which is not removed with its new or entered value.
+			got := make(map[string]string, len(input))
+			for _, in := range input {
+				got[in.GetKey()] = in.GetValue()
+			}
+
+			suite.Equal(expectedEnvVars(vars, tt.set, tt.unset), got)
+		})
+	}
+}
+
+func expectedEnvVars(vars []rewardcloud.EnvironmentEnvVar, set map[string]string, unset []string) map[string]string {
+	want := make(map[string]string, len(vars)+len(set))
+	for _, v := range vars {
+		want[v.GetKey()] = envVarValue(v)
+	}
+
+	for key, value := range set {
+		want[key] = value
+	}
+
+	for _, key := range unset {
+		delete(want, key)
+	}
+
+	return want
+}
+
+func (suite *EnvVarsTestSuite) TestMergeEnvVarsKeepsEncryption() {
+	vars := []rewardcloud.EnvironmentEnvVar{
+		envVar(1, "APP_ENV", "staging", "", false),
+		envVar(2, "API_SECRET", "s3cr3t", "", true),
+	}
+
+	input, _ := mergeEnvVars(vars, map[string]string{"APP_ENV": "production"}, nil, false)
+	suite.Require().Len(input, 2)
+	suite.False(input[0].GetIsEncrypted())
+	suite.Equal("s3cr3t", input[1].GetValue())
+	suite.True(input[1].GetIsEncrypted())
+}
+
+// TestMergeEnvVarsRoundTrip imports the exported variables, which must not touch the encrypted variables and the
+// references.
+func (suite *EnvVarsTestSuite) TestMergeEnvVarsRoundTrip() {
+	vars := []rewardcloud.EnvironmentEnvVar{
+		envVar(1, "APP_ENV", "staging", "", false),
+		envVar(2, "API_SECRET", "s3cr3t", "", true),
+		envVar(3, "BASE_URL", "https://staging.example.org", "https://${HOST}", false),
+		envVar(4, "MIXED", `it's "quoted"`, "", true),
+	}
+
+	var b strings.Builder
+
+	for _, v := range envVarOutputs(vars, true) {
+		b.WriteString(v.Key + "=" + quoteDotEnvValue(v.Value) + "\n")
+	}
+
+	set, err := parseDotEnv(strings.NewReader(b.String()))
+	suite.Require().NoError(err)
+
+	_, changes := mergeEnvVars(vars, set, nil, false)
+	suite.Empty(changes)
+}
+
+func (suite *EnvVarsTestSuite) TestQuoteDotEnvValue() {
+	tests := []struct {
+		name  string
+		value string
+		want  string
+	}{
+		{name: "plain", value: "value", want: "value"},
+		{name: "empty", value: "", want: "''"},
+		{name: "space", value: "a b", want: "'a b'"},
+		{name: "variable", value: "$HOME", want: "'$HOME'"},
+		{name: "backslash", value: `a\b`, want: `'a\b'`},
+		{name: "double quote", value: `say "hi"`, want: `'say "hi"'`},
+		{name: "single quote", value: "it's", want: `"it's"`},
+		{name: "both quotes", value: `it's "$x"`, want: `"it's \"\$x\""`},
+		{name: "newline", value: "a\nb", want: `"a\nb"`},
+		{name: "single quote and backslash", value: `it's\n`, want: `"it's\\n"`},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.Equal(tt.want, quoteDotEnvValue(tt.value))
+		})
+	}
+}
+
+func (suite *EnvVarsTestSuite) TestQuoteDotEnvValueRoundTrip() {
+	values := []string{"value", "", "a b", "$HOME", `a\b`, `say "hi"`, "it's", `it's "$x"`, "a\nb\r\n", `it's\n`}
+
+	var b strings.Builder
+
+	for i, value := range values {
+		b.WriteString("KEY" + string(rune('A'+i)) + "=" + quoteDotEnvValue(value) + "\n")
+	}
+
+	vars, err := parseDotEnv(strings.NewReader(b.String()))
+	suite.Require().NoError(err)
+
+	for i, value := range values {
+		suite.Equal(value, vars["KEY"+string(rune('A'+i))])
+	}
+}
+
+func (suite *EnvVarsTestSuite) TestSecretKeyPattern() {
+	secret := []string{
+		"DB_PASSWORD", "password", "MYSQL_PASS", "SMTP_PASSWD", "APP_SECRET", "SECRET_KEY", "ACCESS_TOKEN", "API_KEY",
+		"APIKEY", "AWS_CREDENTIALS", "PRIVATE_KEY", "BASIC_AUTH_USER",
+	}
+	plain := []string{"MONKEY_MODE", "AUTHOR", "BYPASS_CACHE", "KEYBOARD_LAYOUT", "PASSENGER_COUNT", "TOKENIZER"}
+
+	for _, key := range secret {
+		suite.True(secretKeyPattern.MatchString(key), key)
+	}
+
+	for _, key := range plain {
+		suite.False(secretKeyPattern.MatchString(key), key)
+	}
+}
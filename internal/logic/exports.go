@@ -0,0 +1,485 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/download"
+)
+
+const exportRefLatest = "latest"
+
+func (c *EnvClient) RunCmdEnvExportsDownload(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.Errorf("please specify an export id or %q", exportRefLatest)
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	exported, err := c.resolveExportedData(ctx, args[0], c.GetString("exports_download_type"))
+	if err != nil {
+		return errors.Wrap(err, "getting exported data")
+	}
+
+	profileName := c.GetString("exports_download_strip_profile")
+
+	profile, err := c.getStripProfile(profileName)
+	if err != nil {
+		return err
+	}
+
+	file, err := c.downloadExportedData(ctx, exported, c.GetString("exports_download_output"))
+	if err != nil {
+		return err
+	}
+
+	if profile == nil {
+		return nil
+	}
+
+	return stripDump(ctx, file, profileName, profile)
+}
+
+// resolveExportedData returns the exported data by its id or the latest export of the given type if ref is "latest".
+func (c *EnvClient) resolveExportedData(ctx context.Context, ref, datatype string) (*rewardcloud.ExportedData, error) {
+	if ref == exportRefLatest {
+		return c.latestExportedData(ctx, c.getRcContext(ctx).Environment, normalizeDataType(datatype))
+	}
+
+	exported, _, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasIdGet(ctx, ref).Execute()
+	if err != nil {
+		return nil, errors.Wrapf(err, "getting exported data %s", ref)
+	}
+
+	return exported, nil
+}
+
+// latestExportedData returns the most recent export of the given data type for the environment.
+func (c *EnvClient) latestExportedData(ctx context.Context, envID, datatype string) (*rewardcloud.ExportedData, error) {
+	datatypeID, err := c.GetDatatransferDataTypeID(ctx, datatype)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting data type id")
+	}
+
+	res, _, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasGetCollection(ctx).
+		Environment(envID).
+		DataTransferDataType(datatypeID).
+		OrderCreatedAt("desc").
+		ItemsPerPage(1).
+		Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting exported data")
+	}
+
+	if len(res) < 1 {
+		return nil, errors.Errorf("no exported %s found", strings.ToLower(datatype))
+	}
+
+	return &res[0], nil
+}
+
+// downloadExportedData downloads the exported data to output and returns the path of the downloaded file.
+// If output is empty or a directory, the file name is taken from the url of the export.
+func (c *EnvClient) downloadExportedData(
+	ctx context.Context, exported *rewardcloud.ExportedData, output string,
+) (string, error) {
+	rawURL := exported.GetUrl()
+	if rawURL == "" {
+		return "", errors.Errorf("export %d has no download url", exported.GetId())
+	}
+
+	dest, err := exportDestination(rawURL, output)
+	if err != nil {
+		return "", err
+	}
+
+	if err := c.downloadFile(ctx, rawURL, dest); err != nil {
+		return "", errors.Wrap(err, "downloading exported data")
+	}
+
+	log.Infof("Downloaded to: %s", dest)
+
+	return dest, nil
+}
+
+func exportDestination(rawURL, output string) (string, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return "", errors.Wrap(err, "parsing export url")
+	}
+
+	name := path.Base(u.Path)
+	if name == "." || name == "/" {
+		return "", errors.Errorf("cannot determine file name from url, please specify the output path")
+	}
+
+	if output == "" {
+		return name, nil
+	}
+
+	if fi, err := os.Stat(output); err == nil && fi.IsDir() {
+		return filepath.Join(output, name), nil
+	}
+
+	return output, nil
+}
+
+// downloadFile downloads rawURL to dest and renders a progress bar while the download is running.
+func (c *Client) downloadFile(ctx context.Context, rawURL, dest string) error {
+	err := runWithProgress(ctx, fmt.Sprintf("Downloading %s...", filepath.Base(dest)),
+		func(ctx context.Context, report func(written, total int64)) error {
+			return download.File(ctx, rawURL, dest, download.WithProgress(report))
+		},
+	)
+	if errors.Is(err, ErrTransferAborted) {
+		return errors.New("download interrupted, run the command again to resume")
+	}
+
+	return err
+}
+
+// normalizeDataType converts the data type passed on the command line to the name used by the API.
+func normalizeDataType(datatype string) string {
+	switch strings.ToLower(datatype) {
+	case "db", "database":
+		return DataTypeDatabase
+	case "media":
+		return DataTypeMedia
+	default:
+		return datatype
+	}
+}
+
+const (
+	exportsPageSize = 100
+	// exportSizeConcurrency is the number of the requests run at the same time to get the size of the exports.
+	exportSizeConcurrency = 8
+)
+
+// ExportedDataOutput is the machine-readable representation of an export.
+type ExportedDataOutput struct {
+	ID              int32      `json:"id" yaml:"id"`
+	Type            string     `json:"type" yaml:"type"`
+	Environment     string     `json:"environment" yaml:"environment"`
+	CreatedAt       *time.Time `json:"createdAt" yaml:"createdAt"`
+	CreatedBy       string     `json:"createdBy" yaml:"createdBy"`
+	Size            int64      `json:"size" yaml:"size"`
+	IsStripDatabase bool       `json:"isStripDatabase" yaml:"isStripDatabase"`
+	URL             string     `json:"url" yaml:"url"`
+}
+
+func (c *EnvClient) RunCmdEnvExportsList(cmd *cobra.Command, args []string) error {
+	format := c.GetString("exports_list_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	since, err := parseDateFlag(c.GetString("exports_list_since"), false)
+	if err != nil {
+		return errors.Wrap(err, "parsing --since")
+	}
+
+	until, err := parseDateFlag(c.GetString("exports_list_until"), true)
+	if err != nil {
+		return errors.Wrap(err, "parsing --until")
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	envID := c.GetString("exports_list_environment")
+	if envID == "" {
+		envID = c.getRcContext(ctx).Environment
+	}
+
+	exports, err := c.listExportedData(ctx, envID, c.GetString("exports_list_type"))
+	if err != nil {
+		return err
+	}
+
+	filtered := make([]rewardcloud.ExportedData, 0, len(exports))
+	for _, e := range exports {
+		if !since.IsZero() && e.GetCreatedAt().Before(since) {
+			continue
+		}
+
+		if !until.IsZero() && e.GetCreatedAt().After(until) {
+			continue
+		}
+
+		filtered = append(filtered, e)
+	}
+
+	out, err := c.exportedDataOutputs(ctx, filtered)
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, out)
+	}
+
+	if len(out) == 0 {
+		log.Info("No exported data found.")
+
+		return nil
+	}
+
+	t := NewTableWriter(WithTableWidthMax(120))
+	t.AppendHeader(table.Row{"ID", "Type", "Created At", "Size", "Strip", "URL"})
+
+	for _, o := range out {
+		t.AppendRow(table.Row{o.ID, o.Type, formatTime(o.CreatedAt), formatSize(o.Size), o.IsStripDatabase, o.URL})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvExportsShow(cmd *cobra.Command, args []string) error {
+	if len(args) != 1 {
+		return errors.Errorf("please specify an export id or %q", exportRefLatest)
+	}
+
+	format := c.GetString("exports_show_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	exported, err := c.resolveExportedData(ctx, args[0], c.GetString("exports_show_type"))
+	if err != nil {
+		return errors.Wrap(err, "getting exported data")
+	}
+
+	out, err := c.exportedDataOutputs(ctx, []rewardcloud.ExportedData{*exported})
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, out[0])
+	}
+
+	o := out[0]
+
+	t := NewTableWriter(WithTableWidthMax(120))
+	t.AppendHeader(table.Row{"EXPORT", ""})
+	t.AppendRow(table.Row{"ID", o.ID})
+	t.AppendRow(table.Row{"Type", o.Type})
+	t.AppendRow(table.Row{"Environment", o.Environment})
+	t.AppendRow(table.Row{"Created At", formatTime(o.CreatedAt)})
+	t.AppendRow(table.Row{"Created By", o.CreatedBy})
+	t.AppendRow(table.Row{"Size", formatSize(o.Size)})
+	t.AppendRow(table.Row{"Strip Database", o.IsStripDatabase})
+	t.AppendRow(table.Row{"URL", o.URL})
+	t.Render()
+
+	return nil
+}
+
+//nolint:cyclop
+func (c *EnvClient) RunCmdEnvExportsDelete(cmd *cobra.Command, args []string) error {
+	format := c.GetString("exports_delete_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	olderThan := c.GetString("exports_delete_older_than")
+
+	switch {
+	case len(args) == 1 && olderThan != "":
+		return errors.New("please specify either an export id or --older-than, not both")
+	case len(args) == 0 && olderThan == "":
+		return errors.New("please specify an export id or --older-than")
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	var ids []int32
+
+	if len(args) == 1 {
+		exported, _, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasIdGet(ctx, args[0]).Execute()
+		if err != nil {
+			return errors.Wrapf(err, "getting exported data %s", args[0])
+		}
+
+		ids = append(ids, exported.GetId())
+	} else {
+		age, err := ParseAge(olderThan)
+		if err != nil {
+			return errors.Wrap(err, "parsing --older-than")
+		}
+
+		exports, err := c.listExportedData(ctx, c.getRcContext(ctx).Environment, c.GetString("exports_delete_type"))
+		if err != nil {
+			return err
+		}
+
+		threshold := time.Now().Add(-age)
+		for _, e := range exports {
+			if e.GetCreatedAt().Before(threshold) {
+				ids = append(ids, e.GetId())
+			}
+		}
+	}
+
+	if len(ids) == 0 {
+		log.Info("No exported data to delete.")
+
+		return nil
+	}
+
+	if !c.GetBool("exports_delete_yes") {
+		val, err := GetValueFromPrompt(fmt.Sprintf("Delete %d exported data? [y/n]", len(ids)))
+		if err != nil {
+			return errors.Wrap(err, "getting confirmation")
+		}
+
+		if !isYes(val) {
+			log.Info("Aborted.")
+
+			return nil
+		}
+	}
+
+	deleted := make([]int32, 0, len(ids))
+	for _, id := range ids {
+		_, err := c.RewardCloud.ExportedDataApi.ApiExportedDatasIdDelete(ctx, strconv.FormatInt(int64(id), 10)).Execute()
+		if err != nil {
+			return errors.Wrapf(err, "deleting exported data %d", id)
+		}
+
+		deleted = append(deleted, id)
+
+		if !isStructuredOutput(format) {
+			log.Infof("Deleted exported data %d", id)
+		}
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, map[string][]int32{"deleted": deleted})
+	}
+
+	return nil
+}
+
+// listExportedData returns every export of the environment, optionally filtered by data type, newest first.
+func (c *EnvClient) listExportedData(ctx context.Context, envID, datatype string) ([]rewardcloud.ExportedData, error) {
+	req := c.RewardCloud.ExportedDataApi.ApiExportedDatasGetCollection(ctx).
+		Environment(envID).
+		OrderCreatedAt("desc").
+		ItemsPerPage(exportsPageSize)
+
+	if datatype != "" {
+		datatypeID, err := c.GetDatatransferDataTypeID(ctx, normalizeDataType(datatype))
+		if err != nil {
+			return nil, errors.Wrap(err, "getting data type id")
+		}
+
+		if datatypeID == "" {
+			return nil, errors.Errorf("unknown data type: %s", datatype)
+		}
+
+		req = req.DataTransferDataType(datatypeID)
+	}
+
+	var exports []rewardcloud.ExportedData
+
+	for page := int32(1); ; page++ {
+		res, _, err := req.Page(page).Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting exported data")
+		}
+
+		exports = append(exports, res...)
+
+		if len(res) < exportsPageSize {
+			return exports, nil
+		}
+	}
+}
+
+// exportedDataOutputs converts the exports to their output representation. The size of the exports is not stored
+// by the API, so it is read from the download urls, at most exportSizeConcurrency at a time.
+func (c *EnvClient) exportedDataOutputs(
+	ctx context.Context, exports []rewardcloud.ExportedData,
+) ([]ExportedDataOutput, error) {
+	types, _, err := c.RewardCloud.DataTransferDataTypeApi.ApiDataTransferDataTypesGetCollection(ctx).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting data transfer data types")
+	}
+
+	typeNames := make(map[string]string, len(types))
+	for _, t := range types {
+		typeNames[strconv.FormatInt(int64(t.GetId()), 10)] = t.GetName()
+	}
+
+	out := make([]ExportedDataOutput, len(exports))
+
+	var g errgroup.Group
+	g.SetLimit(exportSizeConcurrency)
+
+	for i, e := range exports {
+		out[i] = ExportedDataOutput{
+			ID:              e.GetId(),
+			Type:            typeNames[GetIDFromPath(e.GetDataTransferDataType())],
+			Environment:     GetIDFromPath(e.GetEnvironment()),
+			CreatedAt:       e.CreatedAt,
+			CreatedBy:       e.GetCreatedBy(),
+			Size:            -1,
+			IsStripDatabase: e.GetIsStripDatabase(),
+			URL:             e.GetUrl(),
+		}
+
+		if e.GetUrl() == "" {
+			continue
+		}
+
+		o := &out[i]
+
+		g.Go(func() error {
+			size, err := download.Size(ctx, o.URL)
+			if err != nil {
+				log.Debugf("Cannot get size of export %d: %s", o.ID, err)
+
+				return nil
+			}
+
+			o.Size = size
+
+			return nil
+		})
+	}
+
+	_ = g.Wait()
+
+	return out, nil
+}
@@ -0,0 +1,89 @@
+package logic
+
+import (
+	"os/user"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+)
+
+// ErrNotGitRepository is returned if the current directory is not inside a git work tree.
+var ErrNotGitRepository = errors.New("not a git repository")
+
+// gitRef is a git reference and the commit it points to.
+type gitRef struct {
+	Ref    string
+	Commit string
+}
+
+// localGitRef returns the branch checked out in the current directory (or the commit if HEAD is detached).
+// If checkPushed is set, it returns an error if the commit has not been pushed, as the server builds from the
+// remote repository.
+func (c *Client) localGitRef(checkPushed bool) (*gitRef, error) {
+	if _, err := c.git("rev-parse", "--is-inside-work-tree"); err != nil {
+		return nil, ErrNotGitRepository
+	}
+
+	commit, err := c.git("rev-parse", "HEAD")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting HEAD commit")
+	}
+
+	branch, err := c.git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current branch")
+	}
+
+	// detached HEAD
+	if branch == "HEAD" {
+		if checkPushed {
+			remotes, err := c.git("branch", "-r", "--contains", commit)
+			if err != nil || remotes == "" {
+				return nil, errors.Errorf("commit %s has not been pushed, please push it first or use --ref", commit)
+			}
+		}
+
+		return &gitRef{Ref: commit, Commit: commit}, nil
+	}
+
+	if checkPushed {
+		upstream, err := c.git("rev-parse", "@{u}")
+		if err != nil {
+			return nil, errors.Errorf("branch %s has no upstream, please push it first or use --ref", branch)
+		}
+
+		if upstream != commit {
+			return nil, errors.Errorf("branch %s differs from its upstream (local: %.8s, remote: %.8s), "+
+				"please push or pull first or use --ref", branch, commit, upstream)
+		}
+	}
+
+	return &gitRef{Ref: branch, Commit: commit}, nil
+}
+
+// gitAuthor returns the email address of the git user or the name of the OS user.
+func (c *Client) gitAuthor() string {
+	if email, err := c.git("config", "user.email"); err == nil && email != "" {
+		return email
+	}
+
+	if u, err := user.Current(); err == nil {
+		return u.Username
+	}
+
+	return "-"
+}
+
+func (c *Client) git(args ...string) (string, error) {
+	out, err := c.Shell.ExecuteWithOptions("git", args,
+		shell.WithSuppressOutput(true),
+		shell.WithCatchOutput(true),
+	)
+	if err != nil {
+		return "", errors.Wrapf(err, "running git %s: %s", strings.Join(args, " "), strings.TrimSpace(string(out)))
+	}
+
+	return strings.TrimSpace(string(out)), nil
+}
@@ -0,0 +1,446 @@
+package logic
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/kube"
+)
+
+// The database import commands are run in the database container. The credentials are read from the container
+// environment and the password is passed in the environment of the client, so it is not visible in the process list.
+const (
+	mysqlImportCommand    = `MYSQL_PWD="$MYSQL_PASSWORD" exec mysql -u"$MYSQL_USER" "$MYSQL_DATABASE"`
+	postgresImportCommand = `PGPASSWORD="$POSTGRES_PASSWORD" exec psql -q -v ON_ERROR_STOP=1 ` +
+		`-U "$POSTGRES_USER" "$POSTGRES_DB"`
+)
+
+// dbImportCommands are the database import commands by database engine.
+var dbImportCommands = map[string]string{
+	DatabaseEngineMySQL:      mysqlImportCommand,
+	DatabaseEngineMariaDB:    mysqlImportCommand,
+	DatabaseEnginePostgreSQL: postgresImportCommand,
+}
+
+func (c *EnvClient) RunCmdEnvImportDB(cmd *cobra.Command, args []string) error {
+	fromExport := c.GetString("import_db_from_export")
+
+	switch {
+	case len(args) == 1 && fromExport != "":
+		return errors.New("please specify either a file or --from-export, not both")
+	case len(args) == 0 && fromExport == "":
+		return errors.New("please specify a file to import or --from-export")
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	environment, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	if !c.GetBool("import_db_yes") {
+		if err := c.guardProductionEnvironment(environment, "overwrite the database of"); err != nil {
+			return err
+		}
+	}
+
+	if fromExport != "" {
+		return c.importFromExport(ctx, environment, fromExport, DataTypeDatabase)
+	}
+
+	return c.importDBFromFile(ctx, args[0])
+}
+
+func (c *EnvClient) RunCmdEnvImportMedia(cmd *cobra.Command, args []string) error {
+	fromExport := c.GetString("import_media_from_export")
+
+	switch {
+	case len(args) == 1 && fromExport != "":
+		return errors.New("please specify either a directory or an archive, or --from-export, not both")
+	case len(args) == 0 && fromExport == "":
+		return errors.New("please specify a directory or an archive to import or --from-export")
+	case fromExport != "" && c.GetString("import_media_path") != "":
+		return errors.New("--path cannot be used with --from-export")
+	}
+
+	var fi os.FileInfo
+
+	if fromExport == "" {
+		var err error
+
+		fi, err = os.Stat(args[0])
+		if err != nil {
+			return errors.Wrap(err, "checking source")
+		}
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	environment, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	if !c.GetBool("import_media_yes") {
+		if err := c.guardProductionEnvironment(environment, "overwrite the media of"); err != nil {
+			return err
+		}
+	}
+
+	if fromExport != "" {
+		return c.importFromExport(ctx, environment, fromExport, DataTypeMedia)
+	}
+
+	return c.importMediaFromFile(ctx, args[0], fi)
+}
+
+func (c *EnvClient) importMediaFromFile(ctx context.Context, source string, fi os.FileInfo) error {
+	target, err := c.prepareKubeTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	projectType, err := c.getProjectType(ctx, target.Project)
+	if err != nil {
+		return errors.Wrap(err, "getting project type")
+	}
+
+	mediaPath := c.GetString("import_media_path")
+	if mediaPath == "" {
+		mediaPath = mediaPathForProjectType(projectType.GetName())
+	}
+
+	podname, err := c.getPodName(ctx, target, ComponentMain)
+	if err != nil {
+		return errors.Wrap(err, "getting main pod")
+	}
+
+	var (
+		open    func() (io.ReadCloser, int64, error)
+		tarFlag string
+	)
+
+	switch {
+	case fi.IsDir():
+		open, tarFlag = tarDirectory(source), "-xf"
+	case strings.HasSuffix(source, ".tar.gz"), strings.HasSuffix(source, ".tgz"):
+		open, tarFlag = openFile(source), "-xzf"
+	case strings.HasSuffix(source, ".tar"):
+		open, tarFlag = openFile(source), "-xf"
+	default:
+		return errors.Errorf("unsupported media source: %s (expected a directory, .tar, .tar.gz or .tgz)", source)
+	}
+
+	err = c.streamToPod(ctx, target, podname, strings.ToLower(projectType.GetName()),
+		fmt.Sprintf("Importing media to %s...", mediaPath), open, nil,
+		"sh", "-c", fmt.Sprintf(`mkdir -p "$0" && tar %s - -C "$0"`, tarFlag), mediaPath,
+	)
+	if err != nil {
+		return errors.Wrap(err, "importing media")
+	}
+
+	log.Info("Media import finished")
+
+	return nil
+}
+
+// importFromExport imports an existing export of the data type (id or "latest") on the server side and waits until
+// the environment is running again.
+func (c *EnvClient) importFromExport(
+	ctx context.Context, environment *rewardcloud.EnvironmentEnvironmentOutput, ref, dataType string,
+) error {
+	exported, err := c.resolveExportedData(ctx, ref, dataType)
+	if err != nil {
+		return errors.Wrap(err, "getting exported data")
+	}
+
+	envID := c.getRcContext(ctx).Environment
+	exportedData := []string{fmt.Sprintf("/api/exported_datas/%d", exported.GetId())}
+
+	if dataType == DataTypeMedia {
+		_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdimportMediaPost(ctx, envID).
+			ExportedData(exportedData).
+			Execute()
+	} else {
+		_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdimportDatabasePost(ctx, envID).
+			ExportedData(exportedData).
+			Execute()
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "importing %s", strings.ToLower(dataType))
+	}
+
+	err = c.trackEnvironmentOperation(ctx, envID,
+		fmt.Sprintf("Importing export %d into %s...", exported.GetId(), environment.GetName()), EnvStateRunning)
+	if err != nil {
+		return errors.Wrapf(err, "importing %s", strings.ToLower(dataType))
+	}
+
+	log.Infof("%s import finished", dataType)
+
+	return nil
+}
+
+func (c *EnvClient) importDBFromFile(ctx context.Context, file string) error {
+	if _, err := os.Stat(file); err != nil {
+		return errors.Wrap(err, "checking database dump")
+	}
+
+	target, err := c.prepareKubeTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	podname, err := c.getPodName(ctx, target, ComponentDB)
+	if err != nil {
+		return errors.Wrap(err, "getting database pod")
+	}
+
+	engine, err := c.getDatabaseEngine(ctx, target.Project)
+	if err != nil {
+		return errors.Wrap(err, "getting database engine")
+	}
+
+	var transform func(io.Reader) (io.Reader, error)
+	if strings.HasSuffix(file, ".gz") {
+		transform = gunzip
+	}
+
+	err = c.streamToPod(ctx, target, podname, "", fmt.Sprintf("Importing %s...", filepath.Base(file)),
+		openFile(file), transform,
+		"sh", "-c", dbImportCommands[engine.Name],
+	)
+	if err != nil {
+		return errors.Wrap(err, "importing database")
+	}
+
+	log.Info("Database import finished")
+
+	return nil
+}
+
+// streamToPod runs the command in the container of the pod with the content returned by open as its standard
+// input. Without a container the default container of the pod is used.
+func (c *Client) streamToPod(
+	ctx context.Context,
+	target *kubeTarget,
+	podname, container, msg string,
+	open func() (io.ReadCloser, int64, error),
+	transform func(io.Reader) (io.Reader, error),
+	command ...string,
+) error {
+	return streamToCommand(ctx, msg, open, transform, func(ctx context.Context, stdin io.Reader) ([]byte, error) {
+		var out bytes.Buffer
+
+		err := target.Kube.Exec(ctx, podname, kube.ExecOptions{
+			Container: container,
+			Command:   command,
+			Stdin:     stdin,
+			Stdout:    &out,
+			Stderr:    &out,
+		})
+
+		return out.Bytes(), err //nolint:wrapcheck
+	})
+}
+
+// streamToCommand calls run with the content returned by open as the standard input of the command while it
+// renders a progress bar. The progress is reported based on the bytes read from open, before transform
+// (e.g. decompression) is applied. The context passed to run is canceled and the input stops if the user quits.
+func streamToCommand(
+	ctx context.Context,
+	msg string,
+	open func() (io.ReadCloser, int64, error),
+	transform func(io.Reader) (io.Reader, error),
+	run func(ctx context.Context, stdin io.Reader) ([]byte, error),
+) error {
+	r, size, err := open()
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	var out []byte
+
+	err = runWithProgress(ctx, msg, func(ctx context.Context, report func(written, total int64)) error {
+		var stdin io.Reader = &contextReader{ctx: ctx, r: newProgressReader(r, size, report)}
+
+		if transform != nil {
+			var err error
+
+			stdin, err = transform(stdin)
+			if err != nil {
+				return err
+			}
+		}
+
+		var runErr error
+
+		out, runErr = run(ctx, stdin)
+
+		return runErr
+	})
+	if err != nil {
+		return errors.Wrapf(err, "command output: %s", string(out))
+	}
+
+	return nil
+}
+
+// openFile returns a function which opens the file and returns its size.
+func openFile(file string) func() (io.ReadCloser, int64, error) {
+	return func() (io.ReadCloser, int64, error) {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "opening file")
+		}
+
+		fi, err := f.Stat()
+		if err != nil {
+			_ = f.Close()
+
+			return nil, 0, errors.Wrap(err, "checking file")
+		}
+
+		return f, fi.Size(), nil
+	}
+}
+
+// gunzip decompresses the reader.
+func gunzip(r io.Reader) (io.Reader, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "reading gzip header")
+	}
+
+	return zr, nil
+}
+
+// tarDirectory returns a function which streams the content of the directory as a tar archive.
+func tarDirectory(dir string) func() (io.ReadCloser, int64, error) {
+	return tarStream(dir, "")
+}
+
+// tarPath returns a function which streams the file or directory as a tar archive with a single top-level entry
+// named after it.
+func tarPath(path string) func() (io.ReadCloser, int64, error) {
+	return tarStream(path, filepath.Base(path))
+}
+
+// tarStream returns a function which streams root as a tar archive with the entries placed under prefix. The size
+// is the sum of the size of the files. The archive is not compressed as media files usually are compressed already.
+func tarStream(root, prefix string) func() (io.ReadCloser, int64, error) {
+	return func() (io.ReadCloser, int64, error) {
+		var size int64
+
+		err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if info.Mode().IsRegular() {
+				size += info.Size()
+			}
+
+			return nil
+		})
+		if err != nil {
+			return nil, 0, errors.Wrap(err, "walking directory")
+		}
+
+		pr, pw := io.Pipe()
+
+		go func() {
+			_ = pw.CloseWithError(writeTar(pw, root, prefix))
+		}()
+
+		return pr, size, nil
+	}
+}
+
+func writeTar(w io.Writer, root, prefix string) error {
+	tw := tar.NewWriter(w)
+
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return errors.Wrap(err, "getting relative path")
+		}
+
+		if rel == "." && prefix == "" {
+			return nil
+		}
+
+		if !info.Mode().IsRegular() && !info.IsDir() {
+			log.Debugf("Skipping %s: not a regular file", path)
+
+			return nil
+		}
+
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return errors.Wrap(err, "creating tar header")
+		}
+
+		hdr.Name = filepath.ToSlash(filepath.Join(prefix, rel))
+
+		if err := tw.WriteHeader(hdr); err != nil {
+			return errors.Wrap(err, "writing tar header")
+		}
+
+		if info.IsDir() {
+			return nil
+		}
+
+		f, err := os.Open(path)
+		if err != nil {
+			return errors.Wrap(err, "opening file")
+		}
+		defer f.Close()
+
+		_, err = io.Copy(tw, f)
+
+		return errors.Wrap(err, "writing file to archive")
+	})
+	if err != nil {
+		return err
+	}
+
+	return errors.Wrap(tw.Close(), "closing tar writer")
+}
+
+// mediaPathForProjectType returns the media directory of the application relative to its document root.
+func mediaPathForProjectType(name string) string {
+	switch strings.ToLower(name) {
+	case "wordpress":
+		return "wp-content/uploads"
+	case "shopware":
+		return "public/media"
+	default:
+		return "pub/media"
+	}
+}
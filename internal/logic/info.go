@@ -3,11 +3,14 @@ package logic
 import (
 	"context"
 	"encoding/base64"
+	"fmt"
 	"net/url"
+	"os"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/internal/config"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
 	"github.com/spf13/cobra"
 )
 
@@ -21,7 +24,11 @@ func NewInfoClient(c *config.App) *InfoClient {
 
 //nolint:funlen,cyclop
 func (c *InfoClient) RunCmdInfo(cmd *cobra.Command, args []string) error {
-	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(context.Background())
+	if err := checkExportFormat(c.GetString("info_export")); err != nil {
+		return err
+	}
+
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "logging in")
 	}
@@ -41,6 +48,10 @@ func (c *InfoClient) RunCmdInfo(cmd *cobra.Command, args []string) error {
 		return errors.Wrap(err, "getting environment")
 	}
 
+	if format, file := c.GetString("info_export"), c.GetString("info_export_file"); format != "" || file != "" {
+		return c.exportCredentials(ctx, project, environment, format, file, c.GetBool("info_export_force"))
+	}
+
 	envState, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
 	if err != nil {
 		return errors.Wrap(err, "getting state")
@@ -146,3 +157,45 @@ func (c *InfoClient) RunCmdInfo(cmd *cobra.Command, args []string) error {
 
 	return nil
 }
+
+// exportCredentials prints the credentials of the environment in the export format or writes them to file. The
+// database port is the local port of the running background port-forward or the port of the database engine.
+func (c *InfoClient) exportCredentials(
+	ctx context.Context, project *rewardcloud.ProjectProjectOutput, environment *rewardcloud.EnvironmentEnvironmentOutput,
+	format, file string, force bool,
+) error {
+	credentials, err := c.getLocalDatabaseCredentials(ctx, project, environment, 0)
+	if err != nil {
+		return err
+	}
+
+	endpoints, err := c.getAccessEndpoints(ctx, environment)
+	if err != nil {
+		return err
+	}
+
+	export := &credentialsExport{
+		Name:            kubeContextName(environmentNamespace(project, environment)),
+		Database:        credentials,
+		Endpoints:       endpoints,
+		IncludePassword: c.GetBool("info_export_include_password"),
+	}
+
+	if file != "" {
+		return writeCredentials(file, format, force, export)
+	}
+
+	b, err := renderCredentials(format, export)
+	if err != nil {
+		return err
+	}
+
+	if format == ExportFormatShell {
+		// The output has to be quoted, otherwise the shell splits and globs the values, e.g. passwords.
+		b = append([]byte(fmt.Sprintf("# eval \"$(%s info --export=shell)\"\n", c.AppName())), b...)
+	}
+
+	_, err = os.Stdout.Write(b)
+
+	return errors.Wrap(err, "writing credentials")
+}
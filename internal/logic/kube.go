@@ -0,0 +1,140 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/kube"
+)
+
+const componentLabel = "reward.itg.cloud/component"
+
+const (
+	ComponentMain = "main"
+	ComponentDB   = "db"
+)
+
+// kubeTarget holds everything needed to access the environment of the current context.
+type kubeTarget struct {
+	Project     *rewardcloud.ProjectProjectOutput
+	Environment *rewardcloud.EnvironmentEnvironmentOutput
+	Cluster     *rewardcloud.Cluster
+	Kubeconfig  string
+	Namespace   string
+	Kube        *kube.Client
+}
+
+// prepareKubeTarget looks up the environment of the current context and writes the kubeconfig of its cluster.
+func (c *Client) prepareKubeTarget(ctx context.Context) (*kubeTarget, error) {
+	project, err := c.getProject(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting project")
+	}
+
+	environment, err := c.getEnvironment(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting environment")
+	}
+
+	cluster, err := c.getCluster(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting cluster")
+	}
+
+	kubeconfig, err := c.prepareKubeconfig(cluster)
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing kubeconfig")
+	}
+
+	namespace := environmentNamespace(project, environment)
+
+	kubeClient, err := kube.NewClient(kubeconfig, namespace)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating kubernetes client")
+	}
+
+	return &kubeTarget{
+		Project:     project,
+		Environment: environment,
+		Cluster:     cluster,
+		Kubeconfig:  kubeconfig,
+		Namespace:   namespace,
+		Kube:        kubeClient,
+	}, nil
+}
+
+// environmentNamespace returns the kubernetes namespace of the environment.
+func environmentNamespace(
+	project *rewardcloud.ProjectProjectOutput, environment *rewardcloud.EnvironmentEnvironmentOutput,
+) string {
+	return fmt.Sprintf("%s-%s", project.GetCodeName(), environment.GetCodeName())
+}
+
+// kubectlArgs prepends the kubeconfig, cache dir and namespace flags of the target to args.
+func (c *Client) kubectlArgs(t *kubeTarget, args ...string) []string {
+	return append([]string{
+		"--kubeconfig", t.Kubeconfig,
+		"--cache-dir", filepath.Join(c.CacheDir(), "kubectl"),
+		"-n", t.Namespace,
+	}, args...)
+}
+
+// getPodName returns the name of a ready pod running the given component of the environment. Pods which are being
+// deleted (e.g. during a rollout) are skipped, and if the component has several replicas the first one is used.
+func (c *Client) getPodName(ctx context.Context, t *kubeTarget, component string) (string, error) {
+	pods, err := c.listPods(ctx, t, component)
+	if err != nil {
+		return "", err
+	}
+
+	pod := readyPod(pods)
+	if pod == nil {
+		return "", errors.Errorf("cannot find a ready %s pod: found %d pods", component, len(pods))
+	}
+
+	return pod.Name, nil
+}
+
+// readyPod returns the first pod by name which is running, ready and not being deleted, or nil if there is none.
+func readyPod(pods []corev1.Pod) *corev1.Pod {
+	var ready *corev1.Pod
+
+	for i := range pods {
+		p := &pods[i]
+		if p.DeletionTimestamp != nil || p.Status.Phase != corev1.PodRunning || !isPodReady(p) {
+			continue
+		}
+
+		if ready == nil || p.Name < ready.Name {
+			ready = p
+		}
+	}
+
+	return ready
+}
+
+func isPodReady(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// listPods returns the pods running the given component of the environment or all of its pods if component is
+// empty.
+func (c *Client) listPods(ctx context.Context, t *kubeTarget, component string) ([]corev1.Pod, error) {
+	var selector string
+	if component != "" {
+		selector = fmt.Sprintf("%s=%s", componentLabel, component)
+	}
+
+	return t.Kube.ListPods(ctx, selector) //nolint:wrapcheck
+}
@@ -0,0 +1,93 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type KubeTestSuite struct {
+	suite.Suite
+}
+
+func TestKubeTestSuite(t *testing.T) {
+	suite.Run(t, new(KubeTestSuite))
+}
+
+func pod(name string, phase corev1.PodPhase, ready, deleting bool) corev1.Pod {
+	p := corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status:     corev1.PodStatus{Phase: phase},
+	}
+
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+
+	p.Status.Conditions = []corev1.PodCondition{{Type: corev1.PodReady, Status: status}}
+
+	if deleting {
+		now := metav1.Now()
+		p.DeletionTimestamp = &now
+	}
+
+	return p
+}
+
+func (suite *KubeTestSuite) TestReadyPod() {
+	tests := []struct {
+		name string
+		pods []corev1.Pod
+		want string
+	}{
+		{
+			name: "single pod",
+			pods: []corev1.Pod{pod("web-a", corev1.PodRunning, true, false)},
+			want: "web-a",
+		},
+		{
+			name: "replicas",
+			pods: []corev1.Pod{
+				pod("web-c", corev1.PodRunning, true, false),
+				pod("web-b", corev1.PodRunning, true, false),
+			},
+			want: "web-b",
+		},
+		{
+			name: "rollout",
+			pods: []corev1.Pod{
+				pod("web-a", corev1.PodRunning, true, true),
+				pod("web-b", corev1.PodRunning, false, false),
+				pod("web-c", corev1.PodRunning, true, false),
+			},
+			want: "web-c",
+		},
+		{
+			name: "not running",
+			pods: []corev1.Pod{
+				pod("web-a", corev1.PodPending, false, false),
+				pod("web-b", corev1.PodSucceeded, false, false),
+			},
+		},
+		{
+			name: "no pods",
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			got := readyPod(tt.pods)
+			if tt.want == "" {
+				suite.Nil(got)
+
+				return
+			}
+
+			suite.Require().NotNil(got)
+			suite.Equal(tt.want, got.Name)
+		})
+	}
+}
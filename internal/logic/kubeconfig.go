@@ -0,0 +1,156 @@
+package logic
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type KubeconfigClient struct {
+	*Client
+}
+
+func NewKubeconfigClient(c *config.App) *KubeconfigClient {
+	return &KubeconfigClient{New(c)}
+}
+
+func (c *KubeconfigClient) RunCmdKubeconfig(cmd *cobra.Command, args []string) error {
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "logging in")
+	}
+
+	project, err := c.getProject(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting project")
+	}
+
+	environment, err := c.getEnvironment(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting environment")
+	}
+
+	namespace := environmentNamespace(project, environment)
+	name := kubeContextName(namespace)
+
+	path := c.GetString("kubeconfig_merge")
+	if path == "" && c.GetBool("kubeconfig_remove") {
+		path = clientcmd.NewDefaultClientConfigLoadingRules().GetDefaultFilename()
+	}
+
+	if c.GetBool("kubeconfig_remove") {
+		return removeKubeContext(path, name)
+	}
+
+	cluster, err := c.getCluster(ctx)
+	if err != nil {
+		return errors.Wrap(err, "getting cluster")
+	}
+
+	kubeconfig, err := c.newKubeconfig(cluster, name, namespace)
+	if err != nil {
+		return err
+	}
+
+	if path == "" || c.GetBool("kubeconfig_print") {
+		fmt.Print(string(kubeconfig))
+	}
+
+	if path == "" {
+		return nil
+	}
+
+	return mergeKubeContext(path, name, kubeconfig)
+}
+
+// kubeContextName returns the name of the kubeconfig context of the environment namespace.
+func kubeContextName(namespace string) string {
+	return fmt.Sprintf("reward-%s", namespace)
+}
+
+// mergeKubeContext adds the context, cluster and user called name from kubeconfig to the kubeconfig file at path.
+// Existing entries with the same name are replaced. The current context is only set if the file has none.
+func mergeKubeContext(path, name string, kubeconfig []byte) error {
+	src, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return errors.Wrap(err, "loading kubeconfig")
+	}
+
+	dest, err := loadKubeconfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	_, exists := dest.Contexts[name]
+
+	dest.Clusters[name] = src.Clusters[name]
+	dest.AuthInfos[name] = src.AuthInfos[name]
+	dest.Contexts[name] = src.Contexts[name]
+
+	if dest.CurrentContext == "" {
+		dest.CurrentContext = name
+	}
+
+	if err := clientcmd.WriteToFile(*dest, path); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+
+	if exists {
+		log.Infof("Context %s updated in %s", name, path)
+	} else {
+		log.Infof("Context %s added to %s", name, path)
+	}
+
+	return nil
+}
+
+// removeKubeContext removes the context, cluster and user called name from the kubeconfig file at path.
+func removeKubeContext(path, name string) error {
+	dest, err := loadKubeconfigFile(path)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := dest.Contexts[name]; !ok {
+		log.Infof("Context %s not found in %s", name, path)
+
+		return nil
+	}
+
+	delete(dest.Contexts, name)
+	delete(dest.Clusters, name)
+	delete(dest.AuthInfos, name)
+
+	if dest.CurrentContext == name {
+		dest.CurrentContext = ""
+	}
+
+	if err := clientcmd.WriteToFile(*dest, path); err != nil {
+		return errors.Wrapf(err, "writing %s", path)
+	}
+
+	log.Infof("Context %s removed from %s", name, path)
+
+	return nil
+}
+
+// loadKubeconfigFile loads the kubeconfig at path or returns an empty config if the file does not exist.
+func loadKubeconfigFile(path string) (*clientcmdapi.Config, error) {
+	conf, err := clientcmd.LoadFromFile(path)
+	if os.IsNotExist(errors.Cause(err)) {
+		return clientcmdapi.NewConfig(), nil
+	}
+
+	if err != nil {
+		return nil, errors.Wrapf(err, "loading %s", path)
+	}
+
+	return conf, nil
+}
@@ -0,0 +1,163 @@
+package logic
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+type KubeconfigTestSuite struct {
+	suite.Suite
+}
+
+func TestKubeconfigTestSuite(t *testing.T) {
+	suite.Run(t, new(KubeconfigTestSuite))
+}
+
+// kubeconfig returns a config with a context, cluster and user for each name using server.
+func (suite *KubeconfigTestSuite) kubeconfig(current, server string, names ...string) *clientcmdapi.Config {
+	conf := clientcmdapi.NewConfig()
+	conf.CurrentContext = current
+
+	for _, name := range names {
+		conf.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+		conf.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: name}
+		conf.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name, Namespace: name}
+	}
+
+	return conf
+}
+
+func (suite *KubeconfigTestSuite) TestMergeKubeContext() {
+	const name = "reward-demo-staging"
+
+	tests := []struct {
+		name        string
+		existing    *clientcmdapi.Config
+		wantCurrent string
+		wantNames   []string
+	}{
+		{
+			name:        "new file",
+			wantCurrent: name,
+			wantNames:   []string{name},
+		},
+		{
+			name:        "add",
+			existing:    suite.kubeconfig("minikube", "https://127.0.0.1:8443", "minikube"),
+			wantCurrent: "minikube",
+			wantNames:   []string{"minikube", name},
+		},
+		{
+			name:        "add without current context",
+			existing:    suite.kubeconfig("", "https://127.0.0.1:8443", "minikube"),
+			wantCurrent: name,
+			wantNames:   []string{"minikube", name},
+		},
+		{
+			name:        "update",
+			existing:    suite.kubeconfig(name, "https://old.example.org", "minikube", name),
+			wantCurrent: name,
+			wantNames:   []string{"minikube", name},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			path := filepath.Join(suite.T().TempDir(), "config")
+			if tt.existing != nil {
+				suite.Require().NoError(clientcmd.WriteToFile(*tt.existing, path))
+			}
+
+			kubeconfig, err := clientcmd.Write(*suite.kubeconfig(name, "https://k8s.example.org", name))
+			suite.Require().NoError(err)
+
+			suite.Require().NoError(mergeKubeContext(path, name, kubeconfig))
+
+			first, err := os.ReadFile(path)
+			suite.Require().NoError(err)
+
+			// Repeated runs update the same entries.
+			suite.Require().NoError(mergeKubeContext(path, name, kubeconfig))
+
+			second, err := os.ReadFile(path)
+			suite.Require().NoError(err)
+			suite.Equal(string(first), string(second))
+
+			conf, err := clientcmd.LoadFromFile(path)
+			suite.Require().NoError(err)
+			suite.Equal(tt.wantCurrent, conf.CurrentContext)
+			suite.Len(conf.Contexts, len(tt.wantNames))
+			suite.Len(conf.Clusters, len(tt.wantNames))
+			suite.Len(conf.AuthInfos, len(tt.wantNames))
+
+			for _, n := range tt.wantNames {
+				suite.Contains(conf.Contexts, n)
+			}
+
+			suite.Equal("https://k8s.example.org", conf.Clusters[name].Server)
+			suite.Equal(name, conf.Contexts[name].Namespace)
+		})
+	}
+}
+
+func (suite *KubeconfigTestSuite) TestRemoveKubeContext() {
+	const name = "reward-demo-staging"
+
+	tests := []struct {
+		name        string
+		existing    *clientcmdapi.Config
+		wantCurrent string
+		wantNames   []string
+	}{
+		{
+			name:        "remove the current context",
+			existing:    suite.kubeconfig(name, "https://k8s.example.org", "minikube", name),
+			wantCurrent: "",
+			wantNames:   []string{"minikube"},
+		},
+		{
+			name:        "remove another context",
+			existing:    suite.kubeconfig("minikube", "https://k8s.example.org", "minikube", name),
+			wantCurrent: "minikube",
+			wantNames:   []string{"minikube"},
+		},
+		{
+			name:        "missing context",
+			existing:    suite.kubeconfig("minikube", "https://k8s.example.org", "minikube"),
+			wantCurrent: "minikube",
+			wantNames:   []string{"minikube"},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			path := filepath.Join(suite.T().TempDir(), "config")
+			suite.Require().NoError(clientcmd.WriteToFile(*tt.existing, path))
+
+			suite.Require().NoError(removeKubeContext(path, name))
+
+			conf, err := clientcmd.LoadFromFile(path)
+			suite.Require().NoError(err)
+			suite.Equal(tt.wantCurrent, conf.CurrentContext)
+			suite.Len(conf.Contexts, len(tt.wantNames))
+			suite.Len(conf.Clusters, len(tt.wantNames))
+			suite.Len(conf.AuthInfos, len(tt.wantNames))
+
+			for _, n := range tt.wantNames {
+				suite.Contains(conf.Contexts, n)
+			}
+		})
+	}
+}
+
+func (suite *KubeconfigTestSuite) TestRemoveKubeContextMissingFile() {
+	path := filepath.Join(suite.T().TempDir(), "config")
+
+	suite.Require().NoError(removeKubeContext(path, "reward-demo-staging"))
+	suite.NoFileExists(path)
+}
@@ -0,0 +1,273 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+)
+
+var nonSlugChars = regexp.MustCompile(`[^a-z0-9]+`)
+
+func (c *EnvClient) RunCmdEnvCreate(cmd *cobra.Command, args []string) error {
+	name, branch := c.GetString("env_create_name"), c.GetString("env_create_branch")
+	if name == "" {
+		name = slugify(branch)
+	}
+
+	if name == "" {
+		return errors.New("please specify the name of the environment using --name or --branch")
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	projectID, err := c.resolveProjectID(ctx, c.GetString("env_create_project"))
+	if err != nil {
+		return err
+	}
+
+	cluster, err := c.resolveCluster(ctx, c.GetString("env_create_cluster"))
+	if err != nil {
+		return err
+	}
+
+	post := rewardcloud.EnvironmentEnvironmentInput{
+		Project: *rewardcloud.NewNullableString(rewardcloud.PtrString(fmt.Sprintf("/api/projects/%s", projectID))),
+		Cluster: *rewardcloud.NewNullableString(rewardcloud.PtrString(fmt.Sprintf("/api/clusters/%d", cluster.GetId()))),
+		Name:    *rewardcloud.NewNullableString(rewardcloud.PtrString(name)),
+	}
+
+	// The API has no dedicated field for the git branch, it is passed to the build as an environment variable.
+	if branch != "" {
+		post.EnvVar = []rewardcloud.EnvironmentEnvVarEnvironmentInput{
+			{
+				Key:   *rewardcloud.NewNullableString(rewardcloud.PtrString(c.GetString("branch_env_var"))),
+				Value: *rewardcloud.NewNullableString(rewardcloud.PtrString(branch)),
+			},
+		}
+	}
+
+	environment, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsPost(ctx).EnvironmentEnvironmentInput(post).Execute()
+	if err != nil {
+		return errors.Wrap(err, "creating environment")
+	}
+
+	log.Infof("Environment %s created with id %d on cluster %s", environment.GetName(), environment.GetId(),
+		cluster.GetName())
+
+	err = c.trackEnvironmentState(ctx, strconv.FormatInt(int64(environment.GetId()), 10),
+		fmt.Sprintf("Deploying environment %s...", environment.GetName()), EnvStateRunning)
+	if err != nil {
+		return errors.Wrap(err, "deploying environment")
+	}
+
+	log.Infof("Environment %s is running", environment.GetName())
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvDelete(cmd *cobra.Command, args []string) error {
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	environment, err := c.resolveEnvironment(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	if !c.GetBool("env_delete_yes") {
+		if err := c.confirmEnvironmentName(environment, "delete"); err != nil {
+			return err
+		}
+	}
+
+	envID := strconv.FormatInt(int64(environment.GetId()), 10)
+
+	_, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdDelete(ctx, envID).Execute()
+	if err != nil {
+		return errors.Wrap(err, "deleting environment")
+	}
+
+	err = c.trackOperation(ctx, fmt.Sprintf("Deleting environment %s...", environment.GetName()),
+		func(ctx context.Context, onChange func(state string)) error {
+			return c.pollEnvironmentDeleted(ctx, envID, onChange)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "deleting environment")
+	}
+
+	log.Infof("Environment %s deleted", environment.GetName())
+
+	if envID == c.getRcContext(ctx).Environment {
+		log.Warn("The deleted environment is used by the current context, please select another one")
+	}
+
+	return nil
+}
+
+func (c *EnvClient) RunCmdEnvStart(cmd *cobra.Command, args []string) error {
+	return c.changeEnvironmentState(cmd.Context(), args, EnvStateRunning, "start", false)
+}
+
+func (c *EnvClient) RunCmdEnvStop(cmd *cobra.Command, args []string) error {
+	return c.changeEnvironmentState(cmd.Context(), args, EnvStateStopped, "stop", !c.GetBool("env_stop_yes"))
+}
+
+// changeEnvironmentState moves the environment into the target state and waits until it reaches it. If guard is
+// set, production environments require a typed confirmation.
+func (c *EnvClient) changeEnvironmentState(ctx context.Context, args []string, target, action string, guard bool) error {
+	ctx, err := c.prepareContext(ctx)
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	environment, err := c.resolveEnvironment(ctx, args)
+	if err != nil {
+		return err
+	}
+
+	state, err := c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
+	if err != nil {
+		return errors.Wrap(err, "getting environment state")
+	}
+
+	if strings.EqualFold(state, target) {
+		log.Infof("Environment %s is already %s", environment.GetName(), target)
+
+		return nil
+	}
+
+	if guard {
+		if err := c.guardProductionEnvironment(environment, action); err != nil {
+			return err
+		}
+	}
+
+	stateIRI, err := c.getStateIRIByName(ctx, target)
+	if err != nil {
+		return err
+	}
+
+	envID := strconv.FormatInt(int64(environment.GetId()), 10)
+
+	patch := rewardcloud.EnvironmentEnvironmentInput{
+		State: *rewardcloud.NewNullableString(rewardcloud.PtrString(stateIRI)),
+	}
+
+	_, _, err = c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdPatch(ctx, envID).EnvironmentEnvironmentInput(patch).Execute()
+	if err != nil {
+		return errors.Wrapf(err, "changing environment state to %s", target)
+	}
+
+	err = c.trackEnvironmentState(ctx, envID,
+		fmt.Sprintf("Changing state of environment %s to %s...", environment.GetName(), target), target)
+	if err != nil {
+		return errors.Wrapf(err, "changing environment state to %s", target)
+	}
+
+	log.Infof("Environment %s is %s", environment.GetName(), target)
+
+	return nil
+}
+
+// resolveEnvironment returns the environment referenced by the first argument (id, codename or name) in the
+// project of the current context. Without arguments the environment of the current context is returned.
+func (c *Client) resolveEnvironment(
+	ctx context.Context, args []string,
+) (*rewardcloud.EnvironmentEnvironmentOutput, error) {
+	if len(args) == 0 {
+		environment, err := c.getEnvironment(ctx)
+
+		return environment, errors.Wrap(err, "getting environment")
+	}
+
+	ref := args[0]
+
+	if _, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		return c.getEnvironmentByID(ctx, ref)
+	}
+
+	for page := int32(1); ; page++ {
+		environments, _, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsGetCollection(ctx).
+			Project(c.getRcContext(ctx).Project).
+			Page(page).
+			ItemsPerPage(environmentsPageSize).
+			Execute()
+		if err != nil {
+			return nil, errors.Wrap(err, "getting environments")
+		}
+
+		for i := range environments {
+			if environments[i].GetCodeName() == ref || strings.EqualFold(environments[i].GetName(), ref) {
+				return &environments[i], nil
+			}
+		}
+
+		if len(environments) < environmentsPageSize {
+			return nil, errors.Errorf("cannot find environment %s", ref)
+		}
+	}
+}
+
+// resolveCluster returns the cluster referenced by ref (id, codename or name) or the default cluster if ref is
+// empty.
+func (c *Client) resolveCluster(ctx context.Context, ref string) (*rewardcloud.Cluster, error) {
+	if _, err := strconv.ParseUint(ref, 10, 32); err == nil {
+		cluster, _, err := c.RewardCloud.ClusterApi.ApiClustersIdGet(ctx, ref).Execute()
+
+		return cluster, errors.Wrap(err, "getting cluster")
+	}
+
+	clusters, _, err := c.RewardCloud.ClusterApi.ApiClustersGetCollection(ctx).Execute()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting clusters")
+	}
+
+	for i := range clusters {
+		switch {
+		case ref == "" && clusters[i].GetIsDefault():
+			return &clusters[i], nil
+		case ref != "" && (clusters[i].GetCodeName() == ref || strings.EqualFold(clusters[i].GetName(), ref)):
+			return &clusters[i], nil
+		}
+	}
+
+	if ref == "" {
+		return nil, errors.New("there is no default cluster, please specify one using --cluster")
+	}
+
+	return nil, errors.Errorf("cannot find cluster %s", ref)
+}
+
+// getStateIRIByName returns the IRI of the state with the given name.
+func (c *Client) getStateIRIByName(ctx context.Context, name string) (string, error) {
+	states, _, err := c.RewardCloud.StateApi.ApiStatesGetCollection(ctx).Execute()
+	if err != nil {
+		return "", errors.Wrap(err, "getting states")
+	}
+
+	for _, s := range states {
+		if strings.EqualFold(s.GetName(), name) {
+			return fmt.Sprintf("/api/states/%d", s.GetId()), nil
+		}
+	}
+
+	return "", errors.Errorf("cannot find state %s", name)
+}
+
+// slugify converts s (e.g. a git branch) to a lowercase name which contains only letters, digits and dashes.
+func slugify(s string) string {
+	return strings.Trim(nonSlugChars.ReplaceAllString(strings.ToLower(s), "-"), "-")
+}
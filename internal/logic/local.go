@@ -0,0 +1,157 @@
+package logic
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/rewardenv/reward/pkg/util"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+)
+
+// localEnvFile is the file in the project directory which describes the local environment.
+const localEnvFile = ".env"
+
+// localEnvironment is the local development environment managed by the parent app (reward).
+type localEnvironment struct {
+	Dir  string
+	Name string
+	Type string
+}
+
+// getLocalEnvironment checks the parent app installation and reads the local environment from the current directory.
+func (c *Client) getLocalEnvironment() (*localEnvironment, error) {
+	if !util.CommandAvailable(c.ParentAppName()) {
+		return nil, errors.Errorf("%s is not installed or not in PATH", c.ParentAppName())
+	}
+
+	if !util.FileExists(c.ParentAppHomeDir()) {
+		return nil, errors.Errorf("%s home directory does not exist: %s, please run `%s install` first",
+			c.ParentAppName(), c.ParentAppHomeDir(), c.ParentAppName())
+	}
+
+	dir, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting current directory")
+	}
+
+	f, err := os.Open(filepath.Join(dir, localEnvFile))
+	if err != nil {
+		return nil, errors.Wrapf(err, "opening %s, please run the command from the root of a %s environment",
+			localEnvFile, c.ParentAppName())
+	}
+	defer f.Close()
+
+	vars, err := parseDotEnv(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "reading %s", localEnvFile)
+	}
+
+	prefix := strings.ToUpper(c.ParentAppName())
+
+	env := &localEnvironment{
+		Dir:  dir,
+		Name: vars[prefix+"_ENV_NAME"],
+		Type: vars[prefix+"_ENV_TYPE"],
+	}
+
+	if env.Name == "" {
+		return nil, errors.Errorf("%s_ENV_NAME is not set in %s", prefix, localEnvFile)
+	}
+
+	return env, nil
+}
+
+// runParentApp runs the parent app (reward) with the same home directory this plugin uses.
+func (c *Client) runParentApp(args []string, opts ...shell.Opt) ([]byte, error) {
+	opts = append(opts, shell.WithEnv(
+		fmt.Sprintf("%s_HOME_DIR=%s", strings.ToUpper(c.ParentAppName()), c.ParentAppHomeDir()),
+	))
+
+	return c.Shell.ExecuteWithOptions(c.ParentAppName(), args, opts...) //nolint:wrapcheck
+}
+
+// pullDir returns the directory where the exports are downloaded before they are imported locally.
+func (c *Client) pullDir() (string, error) {
+	dir := filepath.Join(c.CacheDir(), "pull")
+
+	if err := util.CreateDir(dir, nil); err != nil {
+		return "", errors.Wrap(err, "creating download directory")
+	}
+
+	return dir, nil
+}
+
+// pullExportedData downloads an export of the environment to the pull directory. If ref is empty a new export is
+// created using export, otherwise the export referenced by ref (id or latest) is used.
+func (c *EnvClient) pullExportedData(
+	ctx context.Context,
+	datatype, ref string,
+	export func(ctx context.Context) (*rewardcloud.ExportedData, error),
+) (string, error) {
+	var (
+		exported *rewardcloud.ExportedData
+		err      error
+	)
+
+	if ref != "" {
+		exported, err = c.resolveExportedData(ctx, ref, datatype)
+	} else {
+		exported, err = export(ctx)
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	dir, err := c.pullDir()
+	if err != nil {
+		return "", err
+	}
+
+	return c.downloadExportedData(ctx, exported, dir)
+}
+
+// dotEnvUnescaper unescapes the double-quoted dotenv values written by quoteDotEnvValue.
+var dotEnvUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`, `\$`, `$`, `\n`, "\n", `\r`, "\r")
+
+// parseDotEnv parses the KEY=VALUE lines of a dotenv file. Single-quoted values are taken literally, double-quoted
+// values are unescaped.
+func parseDotEnv(r io.Reader) (map[string]string, error) {
+	vars := make(map[string]string)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			if value[0] == '"' {
+				value = dotEnvUnescaper.Replace(value[1 : len(value)-1])
+			} else {
+				value = value[1 : len(value)-1]
+			}
+		}
+
+		vars[strings.TrimSpace(key)] = value
+	}
+
+	return vars, errors.Wrap(scanner.Err(), "scanning file")
+}
@@ -25,7 +25,7 @@ func NewLoginClient(c *config.App) *LoginClient {
 }
 
 func (c *LoginClient) RunCmdLogin(cmd *cobra.Command, args []string) error {
-	_, err := c.CheckTokenAndLogin(context.Background())
+	_, err := c.CheckTokenAndLogin(cmd.Context())
 	if err != nil {
 		return errors.Wrap(err, "logging in")
 	}
@@ -0,0 +1,160 @@
+package logic
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/rewardenv/reward/pkg/util"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type MediaClient struct {
+	*EnvClient
+}
+
+func NewMediaClient(c *config.App) *MediaClient {
+	return &MediaClient{NewEnvClient(c)}
+}
+
+func (c *MediaClient) RunCmdMediaPull(cmd *cobra.Command, args []string) error {
+	local, err := c.getLocalEnvironment()
+	if err != nil {
+		return errors.Wrap(err, "checking local environment")
+	}
+
+	ctx, err := c.prepareContext(cmd.Context())
+	if err != nil {
+		return errors.Wrap(err, "preparing context")
+	}
+
+	mediaPath := c.GetString("media_pull_path")
+	if mediaPath == "" {
+		project, err := c.getProject(ctx)
+		if err != nil {
+			return errors.Wrap(err, "getting project")
+		}
+
+		projectType, err := c.getProjectType(ctx, project)
+		if err != nil {
+			return errors.Wrap(err, "getting project type")
+		}
+
+		mediaPath = mediaPathForProjectType(projectType.GetName())
+	}
+
+	if !filepath.IsAbs(mediaPath) {
+		mediaPath = filepath.Join(local.Dir, mediaPath)
+	}
+
+	file, err := c.pullExportedData(ctx, DataTypeMedia, c.GetString("media_pull_from_export"), c.exportMedia)
+	if err != nil {
+		return err
+	}
+
+	if err := util.CreateDir(mediaPath, nil); err != nil {
+		return errors.Wrap(err, "creating media directory")
+	}
+
+	err = streamToCommand(ctx, fmt.Sprintf("Extracting %s to %s...", filepath.Base(file), mediaPath),
+		openFile(file), nil,
+		func(_ context.Context, r io.Reader) ([]byte, error) {
+			return nil, extractArchive(r, file, mediaPath)
+		},
+	)
+	if err != nil {
+		return errors.Wrap(err, "extracting media")
+	}
+
+	if !c.GetBool("media_pull_keep") {
+		if err := os.Remove(file); err != nil {
+			log.Warnf("Cannot remove %s: %s", file, err)
+		}
+	}
+
+	log.Infof("Media synced to %s", mediaPath)
+
+	return nil
+}
+
+// extractArchive extracts the .tar, .tar.gz, .tgz or .zip archive read from r to dest. Existing files are
+// overwritten.
+func extractArchive(r io.Reader, name, dest string) error {
+	switch {
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		zr, err := gunzip(r)
+		if err != nil {
+			return err
+		}
+
+		return extractTar(zr, dest)
+	case strings.HasSuffix(name, ".tar"):
+		return extractTar(r, dest)
+	case strings.HasSuffix(name, ".zip"):
+		_, err := util.Unzip(r, dest)
+
+		return errors.Wrap(err, "extracting zip archive")
+	default:
+		return errors.Errorf("unsupported archive: %s (expected .tar, .tar.gz, .tgz or .zip)", name)
+	}
+}
+
+func extractTar(r io.Reader, dest string) error {
+	dest = filepath.Clean(dest)
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "reading tar archive")
+		}
+
+		target := filepath.Join(dest, hdr.Name) //nolint:gosec
+		if target != dest && !strings.HasPrefix(target, dest+string(os.PathSeparator)) {
+			return errors.Errorf("%s: illegal file path", hdr.Name)
+		}
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, os.ModePerm); err != nil {
+				return errors.Wrap(err, "creating directory")
+			}
+		case tar.TypeReg:
+			if err := writeFileFromReader(target, tr, hdr.FileInfo().Mode()); err != nil {
+				return err
+			}
+		default:
+			log.Debugf("Skipping %s: not a regular file", hdr.Name)
+		}
+	}
+}
+
+func writeFileFromReader(path string, r io.Reader, mode os.FileMode) error {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return errors.Wrap(err, "creating directory")
+	}
+
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return errors.Wrap(err, "opening file")
+	}
+	defer f.Close()
+
+	if _, err := io.Copy(f, r); err != nil { //nolint:gosec
+		return errors.Wrap(err, "writing file")
+	}
+
+	return errors.Wrap(f.Close(), "closing file")
+}
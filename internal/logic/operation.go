@@ -0,0 +1,168 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/ui"
+)
+
+var (
+	operationPollInterval = 3 * time.Second
+	// operationTimeout is the maximum time to wait for a long-running operation.
+	operationTimeout = 30 * time.Minute
+)
+
+// ErrOperationAborted is returned when the user stops waiting for a long-running operation.
+// The operation itself keeps running on the server.
+var ErrOperationAborted = errors.New("stopped waiting for the operation, it continues in the background")
+
+// trackEnvironmentOperation waits for a long-running operation on an environment to finish.
+// Operations move the environment out of the target state first and back into it when they are done,
+// so the tracker waits for both transitions while it renders the current state next to a spinner.
+func (c *Client) trackEnvironmentOperation(ctx context.Context, envID, msg, target string) error {
+	return c.trackOperation(ctx, msg, func(ctx context.Context, onChange func(state string)) error {
+		return c.pollEnvironmentState(ctx, envID, target, true, onChange)
+	})
+}
+
+// trackEnvironmentState waits for the environment to reach the target state, e.g. after the state was changed.
+func (c *Client) trackEnvironmentState(ctx context.Context, envID, msg, target string) error {
+	return c.trackOperation(ctx, msg, func(ctx context.Context, onChange func(state string)) error {
+		return c.pollEnvironmentState(ctx, envID, target, false, onChange)
+	})
+}
+
+// trackOperation renders a spinner with the state reported by poll until poll returns.
+func (c *Client) trackOperation(
+	ctx context.Context, msg string, poll func(ctx context.Context, onChange func(state string)) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(ui.NewModel(msg))
+
+	var g errgroup.Group
+	g.Go(func() error {
+		defer p.Send(ui.ResultMsg{Ready: true})
+
+		return poll(ctx, func(state string) {
+			p.Send(ui.ResultMsg{Msg: fmt.Sprintf("Environment status: %s", state)})
+		})
+	})
+
+	if _, err := p.Run(); err != nil {
+		cancel()
+		_ = g.Wait()
+
+		return errors.Wrap(err, "running progress ui")
+	}
+
+	// The ui returns either when the poller finished or when the user quit, in the latter case the poller
+	// has to be stopped.
+	cancel()
+
+	err := g.Wait()
+	if errors.Is(err, context.Canceled) {
+		return ErrOperationAborted
+	}
+
+	if err != nil {
+		return errors.Wrap(err, "waiting for environment")
+	}
+
+	return nil
+}
+
+// pollEnvironmentState polls the environment state until it reaches the target state. If leave is set, the
+// environment has to leave the target state first, as operations start from the target state. It gives up after
+// operationTimeout.
+func (c *Client) pollEnvironmentState(
+	ctx context.Context, envID, target string, leave bool, onChange func(state string),
+) error {
+	timeout := time.After(operationTimeout)
+	left := !leave
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-timeout:
+			return errors.Errorf("environment did not reach the %s state in %s", target, operationTimeout)
+		case <-time.After(operationPollInterval):
+		}
+
+		ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+		if err != nil {
+			return errors.Wrap(err, "logging in")
+		}
+
+		environment, err := c.getEnvironmentByID(ctx, envID)
+		if err != nil {
+			return errors.Wrap(err, "getting environment")
+		}
+
+		state := envStatePending
+		if environment.GetState() != "" {
+			state, err = c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
+			if err != nil {
+				return errors.Wrap(err, "getting state")
+			}
+		}
+
+		if !strings.EqualFold(state, target) {
+			left = true
+		} else if left {
+			return nil
+		}
+
+		onChange(state)
+	}
+}
+
+// pollEnvironmentDeleted polls the environment until the API does not return it anymore. It gives up after
+// operationTimeout.
+func (c *Client) pollEnvironmentDeleted(ctx context.Context, envID string, onChange func(state string)) error {
+	timeout := time.After(operationTimeout)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err() //nolint:wrapcheck
+		case <-timeout:
+			return errors.Errorf("environment was not deleted in %s", operationTimeout)
+		case <-time.After(operationPollInterval):
+		}
+
+		ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+		if err != nil {
+			return errors.Wrap(err, "logging in")
+		}
+
+		environment, resp, err := c.RewardCloud.EnvironmentApi.ApiEnvironmentsIdGet(ctx, envID).Execute()
+		if resp != nil && resp.StatusCode == http.StatusNotFound {
+			return nil
+		}
+
+		if err != nil {
+			return errors.Wrap(err, "getting environment")
+		}
+
+		state := envStatePending
+		if environment.GetState() != "" {
+			state, err = c.getStateNameByID(ctx, GetIDFromPath(environment.GetState()))
+			if err != nil {
+				return errors.Wrap(err, "getting state")
+			}
+		}
+
+		onChange(state)
+	}
+}
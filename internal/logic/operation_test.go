@@ -0,0 +1,141 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type OperationTestSuite struct {
+	suite.Suite
+
+	pollInterval time.Duration
+	timeout      time.Duration
+}
+
+func TestOperationTestSuite(t *testing.T) {
+	suite.Run(t, new(OperationTestSuite))
+}
+
+func (suite *OperationTestSuite) SetupTest() {
+	suite.pollInterval, suite.timeout = operationPollInterval, operationTimeout
+	operationPollInterval, operationTimeout = time.Millisecond, time.Second
+}
+
+func (suite *OperationTestSuite) TearDownTest() {
+	operationPollInterval, operationTimeout = suite.pollInterval, suite.timeout
+}
+
+// client returns a client of a fake API which returns the states one after the other for the environment and
+// repeats the last state.
+func (suite *OperationTestSuite) client(states ...string) *Client {
+	var (
+		mu    sync.Mutex
+		polls int
+	)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/projects", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `[]`)
+	})
+	mux.HandleFunc("/api/environments/1", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		state := states[polls]
+		if polls < len(states)-1 {
+			polls++
+		}
+		mu.Unlock()
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":1,"name":"staging","state":"/api/states/%s"}`, state)
+	})
+	mux.HandleFunc("/api/states/", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"id":1,"name":%q}`, path.Base(r.URL.Path))
+	})
+
+	server := httptest.NewServer(mux)
+	suite.T().Cleanup(server.Close)
+
+	app := config.New("cloud", "reward", "v0.0.1")
+	app.Set(app.ConfigPrefix()+"_token_file", filepath.Join(suite.T().TempDir(), "token"))
+	app.RewardCloud = rewardcloud.NewAPIClient(&rewardcloud.Configuration{
+		Servers: rewardcloud.ServerConfigurations{{URL: server.URL}},
+	})
+
+	return New(app)
+}
+
+func (suite *OperationTestSuite) TestPollEnvironmentState() {
+	tests := []struct {
+		name    string
+		states  []string
+		leave   bool
+		want    []string
+		wantErr bool
+	}{
+		{
+			name:   "state change already in the target state",
+			states: []string{EnvStateRunning},
+			want:   nil,
+		},
+		{
+			name:   "state change",
+			states: []string{"pending", "deploying", EnvStateRunning},
+			want:   []string{"pending", "deploying"},
+		},
+		{
+			name:   "operation",
+			states: []string{EnvStateRunning, "building", EnvStateRunning},
+			leave:  true,
+			want:   []string{EnvStateRunning, "building"},
+		},
+		{
+			name:    "operation which does not finish",
+			states:  []string{EnvStateRunning, "building"},
+			leave:   true,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			var got []string
+
+			err := suite.client(tt.states...).pollEnvironmentState(context.Background(), "1", EnvStateRunning,
+				tt.leave, func(state string) {
+					if len(got) == 0 || got[len(got)-1] != state {
+						got = append(got, state)
+					}
+				})
+			if tt.wantErr {
+				suite.ErrorContains(err, "did not reach the running state")
+
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, got)
+		})
+	}
+}
+
+func (suite *OperationTestSuite) TestPollEnvironmentStateCanceled() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := suite.client(EnvStateRunning).pollEnvironmentState(ctx, "1", EnvStateRunning, true, func(string) {})
+	suite.ErrorIs(err, context.Canceled)
+}
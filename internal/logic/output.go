@@ -0,0 +1,78 @@
+package logic
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/pkg/errors"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	OutputFormatTable = "table"
+	OutputFormatJSON  = "json"
+	OutputFormatYAML  = "yaml"
+	// OutputFormatDotEnv writes KEY=VALUE lines.
+	OutputFormatDotEnv = "dotenv"
+)
+
+// ErrUnsupportedOutputFormat is returned when the requested output format is unknown.
+var ErrUnsupportedOutputFormat = errors.New("unsupported output format")
+
+// isStructuredOutput returns true if the output format is a machine-readable one.
+func isStructuredOutput(format string) bool {
+	switch strings.ToLower(format) {
+	case OutputFormatJSON, OutputFormatYAML:
+		return true
+	default:
+		return false
+	}
+}
+
+// checkOutputFormat returns an error if the format is not one of the supported ones.
+func checkOutputFormat(format string, supported ...string) error {
+	if format == "" {
+		return nil
+	}
+
+	for _, s := range supported {
+		if strings.EqualFold(format, s) {
+			return nil
+		}
+	}
+
+	return errors.Wrapf(ErrUnsupportedOutputFormat, "%s (options: %s)", format, strings.Join(supported, ", "))
+}
+
+// printStructured writes v to stdout in the given machine-readable format.
+func printStructured(format string, v interface{}) error {
+	return writeStructured(os.Stdout, format, v)
+}
+
+func writeStructured(w io.Writer, format string, v interface{}) error {
+	var (
+		out []byte
+		err error
+	)
+
+	switch strings.ToLower(format) {
+	case OutputFormatJSON:
+		out, err = json.MarshalIndent(v, "", "  ")
+		out = append(out, '\n')
+	case OutputFormatYAML:
+		out, err = yaml.Marshal(v)
+	default:
+		return errors.Wrap(ErrUnsupportedOutputFormat, format)
+	}
+
+	if err != nil {
+		return errors.Wrapf(err, "marshalling %s output", format)
+	}
+
+	_, err = fmt.Fprint(w, string(out))
+
+	return errors.Wrap(err, "writing output")
+}
@@ -0,0 +1,64 @@
+package logic
+
+import (
+	"context"
+	"os/exec"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type PassthroughClient struct {
+	*Client
+}
+
+func NewPassthroughClient(c *config.App) *PassthroughClient {
+	return &PassthroughClient{New(c)}
+}
+
+// RunCmdKubectl runs kubectl with args against the environment of the current context.
+func (c *PassthroughClient) RunCmdKubectl(cmd *cobra.Command, args []string) error {
+	if err := c.CheckKubectl(); err != nil {
+		return errors.Wrap(err, "checking kubectl")
+	}
+
+	return c.passthrough(cmd.Context(), "kubectl", func(t *kubeTarget) []string {
+		return c.kubectlArgs(t, args...)
+	})
+}
+
+// RunCmdK9s runs k9s with args against the environment of the current context.
+func (c *PassthroughClient) RunCmdK9s(cmd *cobra.Command, args []string) error {
+	if _, err := exec.LookPath("k9s"); err != nil {
+		return errors.Errorf("%s: please install k9s", err)
+	}
+
+	return c.passthrough(cmd.Context(), "k9s", func(t *kubeTarget) []string {
+		return append([]string{"--kubeconfig", t.Kubeconfig, "-n", t.Namespace}, args...)
+	})
+}
+
+// passthrough runs the tool with the arguments returned by args. The standard streams are passed to the tool and
+// its exit code is returned as ExitCodeError.
+func (c *PassthroughClient) passthrough(ctx context.Context, name string, args func(t *kubeTarget) []string) error {
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
+	if err != nil {
+		return errors.Wrap(err, "logging in")
+	}
+
+	target, err := c.prepareKubeTarget(ctx)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.Shell.ExecuteWithOptions(name, args(target))
+
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return &ExitCodeError{Code: exitErr.ExitCode()}
+	}
+
+	return errors.Wrapf(err, "running %s", name)
+}
@@ -3,19 +3,39 @@ package logic
 import (
 	"context"
 	"encoding/base64"
-	"encoding/json"
-	"fmt"
-	"path/filepath"
-	"strings"
+	"net"
+	"strconv"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/pkg/errors"
-	"github.com/rewardenv/reward-cloud-cli/internal/config"
-	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+	"github.com/rewardenv/reward-cloud-sdk-go/rewardcloud"
 	"github.com/spf13/cobra"
-	corev1 "golang.org/x/build/kubernetes/api"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
 )
 
+// DatabaseCredentials is the machine-readable representation of the credentials of a forwarded database.
+type DatabaseCredentials struct {
+	Engine   string `json:"engine" yaml:"engine"`
+	Version  string `json:"version" yaml:"version"`
+	Host     string `json:"host" yaml:"host"`
+	Port     int    `json:"port" yaml:"port"`
+	Schema   string `json:"schema" yaml:"schema"`
+	User     string `json:"user" yaml:"user"`
+	Password string `json:"password" yaml:"password"`
+	URL      string `json:"url" yaml:"url"`
+}
+
+func (d *DatabaseCredentials) engine() DatabaseEngine {
+	return DatabaseEngine{Name: d.Engine, Version: d.Version}
+}
+
+// setPort sets the local port of the database and updates the connection string.
+func (d *DatabaseCredentials) setPort(port int) {
+	d.Port = port
+	d.URL = d.engine().URL(d.Host, d.Port, d.Schema, d.User, d.Password)
+}
+
 type PortForwardClient struct {
 	*Client
 }
@@ -24,130 +44,293 @@ func NewPortForwardClient(c *config.App) *PortForwardClient {
 	return &PortForwardClient{New(c)}
 }
 
-//nolint:funlen,cyclop
-func (c *PortForwardClient) RunCmdPortForwardDB(cmd *cobra.Command, args []string) error {
-	err := c.CheckKubectl()
-	if err != nil {
-		return errors.Wrap(err, "checking kubectl")
+// RunCmdPortForward forwards local ports to the services of the environment or lists the available services.
+func (c *PortForwardClient) RunCmdPortForward(cmd *cobra.Command, args []string) error {
+	list := c.GetBool("port_forward_list")
+	if !list && len(args) == 0 {
+		return cmd.Help() //nolint:wrapcheck
 	}
 
-	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(context.Background())
+	format := c.GetString("port_forward_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	if list {
+		ctx, target, err := c.preparePortForward(cmd.Context(), false)
+		if err != nil {
+			return err
+		}
+
+		return c.listForwardServices(ctx, target, format)
+	}
+
+	specs, err := c.parseTunnelSpecs(args)
 	if err != nil {
-		return errors.Wrap(err, "logging in")
+		return err
+	}
+
+	if localPort := c.GetString("port_forward_local_port"); localPort != "" {
+		if len(specs) != 1 {
+			return errors.New("--local-port can only be used with a single service, use service:port instead")
+		}
+
+		specs[0].LocalPort, err = parseLocalPort(localPort)
+		if err != nil {
+			return err
+		}
+
+		specs[0].explicitLocalPort = true
 	}
 
-	project, err := c.getProject(ctx)
+	if c.GetBool("port_forward_background") && !c.isPortForwardDaemon() {
+		if _, _, err := c.preparePortForward(cmd.Context(), true); err != nil {
+			return err
+		}
+
+		state, err := c.startPortForwardDaemon(cmd.Flags().Lookup("output"))
+		if err != nil {
+			return err
+		}
+
+		if isStructuredOutput(format) {
+			return printStructured(format, state)
+		}
+
+		printPortForwardDaemon(state, c.AppName())
+
+		return nil
+	}
+
+	ctx, target, err := c.preparePortForward(cmd.Context(), true)
 	if err != nil {
-		return errors.Wrap(err, "getting project")
+		return err
+	}
+
+	if hasDatabaseSpec(specs) {
+		engine, err := c.getDatabaseEngine(ctx, target.Project)
+		if err != nil {
+			return errors.Wrap(err, "getting database engine")
+		}
+
+		applyDatabaseEngine(specs, engine)
 	}
 
-	projectTypeVersion, _, err := c.RewardCloud.ProjectTypeVersionApi.ApiProjectTypeVersionsIdGet(
-		ctx, GetIDFromPath(project.GetProjectTypeVersion())).Execute()
+	tunnels, err := listenTunnels(specs)
 	if err != nil {
-		return errors.Wrap(err, "getting project type version")
+		return err
+	}
+	defer closeTunnels(tunnels)
+
+	if isStructuredOutput(format) {
+		statuses := make([]TunnelStatus, 0, len(tunnels))
+		for _, t := range tunnels {
+			statuses = append(statuses, t.status())
+		}
+
+		if err := printStructured(format, statuses); err != nil {
+			return err
+		}
 	}
 
-	_, _, err = c.RewardCloud.ProjectTypeApi.ApiProjectTypesIdGet(
-		ctx, GetIDFromPath(projectTypeVersion.GetProjectType())).Execute()
+	return c.forward(ctx, target, tunnels, !isStructuredOutput(format))
+}
+
+// RunCmdPortForwardDB forwards the database and prints its credentials. Further services to forward in the same
+// session can be passed as arguments.
+func (c *PortForwardClient) RunCmdPortForwardDB(cmd *cobra.Command, args []string) error {
+	format := c.GetString("port_forward_db_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	if err := checkExportFormat(c.GetString("port_forward_db_write_config_format")); err != nil {
+		return err
+	}
+
+	db := "db"
+	if localPort := c.GetString("local_port"); localPort != "" {
+		db += ":" + localPort
+	}
+
+	specs, err := c.parseTunnelSpecs(append([]string{db}, args...))
 	if err != nil {
-		return errors.Wrap(err, "getting project type")
+		return err
 	}
 
-	environment, err := c.getEnvironment(ctx)
+	ctx, target, err := c.preparePortForward(cmd.Context(), true)
 	if err != nil {
-		return errors.Wrap(err, "getting environment")
+		return err
 	}
 
-	state, _, err := c.RewardCloud.StateApi.ApiStatesIdGet(ctx, GetIDFromPath(environment.GetState())).Execute()
+	credentials, err := c.getDatabaseCredentials(ctx, target.Project, target.Environment)
 	if err != nil {
-		return errors.Wrap(err, "getting environment state")
+		return err
+	}
+
+	applyDatabaseEngine(specs, credentials.engine())
+
+	var (
+		tunnels []*tunnel
+		state   *PortForwardState
+	)
+
+	if c.GetBool("port_forward_db_background") && !c.isPortForwardDaemon() {
+		// The credentials are printed and written by this process only.
+		state, err = c.startPortForwardDaemon(cmd.Flags().Lookup("output"), cmd.Flags().Lookup("write-config"),
+			cmd.Flags().Lookup("write-config-format"), cmd.Flags().Lookup("force"),
+			cmd.Flags().Lookup("include-password"))
+		if err != nil {
+			return err
+		}
+
+		port, err := addressPort(state.Services[0].LocalAddress)
+		if err != nil {
+			return err
+		}
+
+		credentials.setPort(port)
+	} else {
+		tunnels, err = listenTunnels(specs)
+		if err != nil {
+			return err
+		}
+		defer closeTunnels(tunnels)
+
+		credentials.setPort(tunnels[0].LocalPort)
 	}
-	if state.GetName() != "Running" {
-		return errors.Errorf("environment is not running: state = %s", state.GetName())
+
+	if c.isPortForwardDaemon() {
+		return c.forward(ctx, target, tunnels, false)
 	}
 
-	cluster, err := c.getCluster(ctx)
-	if err != nil {
-		return errors.Wrap(err, "getting cluster")
+	if path := c.GetString("port_forward_db_write_config"); path != "" {
+		endpoints, err := c.getAccessEndpoints(ctx, target.Environment)
+		if err != nil {
+			return err
+		}
+
+		export := &credentialsExport{
+			Name:            kubeContextName(target.Namespace),
+			Database:        credentials,
+			Endpoints:       endpoints,
+			IncludePassword: c.GetBool("port_forward_db_write_config_include_password"),
+		}
+
+		err = writeCredentials(path, c.GetString("port_forward_db_write_config_format"),
+			c.GetBool("port_forward_db_write_config_force"), export)
+		if err != nil {
+			return err
+		}
+	}
+
+	if isStructuredOutput(format) {
+		if err := printStructured(format, credentials); err != nil {
+			return err
+		}
+	} else {
+		t := NewTableWriter(WithTableWidthMax(80))
+		t.AppendHeader(table.Row{"Engine", "Host", "Port", credentials.engine().SchemaLabel(), "User", "Password"})
+		t.AppendRow(table.Row{
+			credentials.engine().Title(),
+			credentials.Host,
+			credentials.Port,
+			credentials.Schema,
+			credentials.User,
+			credentials.Password,
+		})
+		t.Render()
 	}
 
-	kubeconfigFile, err := c.prepareKubeconfig(cluster)
+	if state != nil {
+		if !isStructuredOutput(format) {
+			printPortForwardDaemon(state, c.AppName())
+		}
+
+		return nil
+	}
+
+	return c.forward(ctx, target, tunnels, !isStructuredOutput(format))
+}
+
+// preparePortForward logs in and looks up the environment of the current context. If running is set, the
+// environment has to be running.
+func (c *PortForwardClient) preparePortForward(ctx context.Context, running bool) (context.Context, *kubeTarget, error) {
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
 	if err != nil {
-		return errors.Wrap(err, "preparing kubeconfig")
-	}
-
-	out, err := c.Kubectl.RunCommand([]string{
-		"--kubeconfig", kubeconfigFile.Name(),
-		"--cache-dir", filepath.Join(c.CacheDir(), "kubectl"),
-		"get",
-		"pod",
-		"-n", fmt.Sprintf("%s-%s", project.GetCodeName(), environment.GetCodeName()),
-		"--selector", "reward.itg.cloud/component=db",
-		"-o", "jsonpath={.items[0].metadata.name}",
-	},
-		shell.WithSuppressOutput(true), shell.WithCatchOutput(true))
+		return nil, nil, errors.Wrap(err, "logging in")
+	}
+
+	target, err := c.prepareKubeTarget(ctx)
 	if err != nil {
-		return errors.Wrap(err, "running kubectl get pod")
+		return nil, nil, err
 	}
 
-	// Remove "Opening in existing browser session.\n" from output
-	if strings.Contains(string(out), "Opening in existing browser session.") {
-		newout := strings.TrimPrefix(string(out), "Opening in existing browser session.\n")
-		out = []byte(newout)
+	if running {
+		if err := c.checkEnvironmentRunning(ctx, target.Environment); err != nil {
+			return nil, nil, err
+		}
 	}
 
-	var obj corev1.PodList
-	err = json.Unmarshal(out, &obj)
+	return ctx, target, nil
+}
+
+// forward runs the tunnels until the context is canceled, e.g. when the process is interrupted. Background port-forwards record their state while
+// they are running.
+func (c *PortForwardClient) forward(ctx context.Context, target *kubeTarget, tunnels []*tunnel, interactive bool,
+) error {
+	cleanup, err := c.recordPortForwardDaemon(ctx, target, tunnels)
 	if err != nil {
-		return errors.Wrapf(err, "unmarshalling pod list, command output: %s", string(out))
+		return err
 	}
+	defer cleanup()
 
-	if len(obj.Items) != 1 {
-		return errors.Errorf("cannot find shell pod, command output: %s", string(out))
-	}
+	return c.runTunnels(ctx, target, tunnels, interactive)
+}
 
+// getDatabaseCredentials returns the engine and the credentials of the database of the environment. The port is
+// left empty as it depends on the tunnel, use setPort to set it.
+func (c *Client) getDatabaseCredentials(
+	ctx context.Context, project *rewardcloud.ProjectProjectOutput, environment *rewardcloud.EnvironmentEnvironmentOutput,
+) (*DatabaseCredentials, error) {
 	access, _, err := c.RewardCloud.EnvironmentAccessApi.ApiEnvironmentAccessesIdGet(
 		ctx, GetIDFromPath(environment.GetEnvironmentAccess())).Execute()
 	if err != nil {
-		return errors.Wrap(err, "getting accesses")
+		return nil, errors.Wrap(err, "getting accesses")
 	}
 
 	database, _, err := c.RewardCloud.EnvironmentAccessDatabaseApi.ApiEnvironmentAccessDatabasesIdGet(
 		ctx, GetIDFromPath(access.GetDatabase())).Execute()
 	if err != nil {
-		return errors.Wrap(err, "getting database")
+		return nil, errors.Wrap(err, "getting database")
 	}
 
 	dbPassword, err := base64.StdEncoding.DecodeString(database.GetPassword())
 	if err != nil {
-		return errors.Wrap(err, "decoding database password")
-	}
-
-	t := NewTableWriter(WithTableWidthMax(80))
-	t.AppendHeader(table.Row{"Host", "Port", "Schema", "User", "Password"})
-	t.AppendRow(table.Row{
-		"127.0.0.1",
-		c.Get("local_port"),
-		database.GetScheme(),
-		database.GetUsername(),
-		string(dbPassword),
-	})
-	t.Render()
-
-	podname := obj.Items[0].Name
-	_, err = c.Kubectl.RunCommand([]string{
-		"--kubeconfig", kubeconfigFile.Name(),
-		"--cache-dir", filepath.Join(c.CacheDir(), "kubectl"),
-		"port-forward",
-		"-n", fmt.Sprintf("%s-%s", project.GetCodeName(), environment.GetCodeName()),
-		podname,
-		fmt.Sprintf("%v:3306", c.Get("local_port")),
-	},
-		shell.WithSuppressOutput(false), shell.WithCatchOutput(false))
+		return nil, errors.Wrap(err, "decoding database password")
+	}
+
+	engine, err := c.getDatabaseEngine(ctx, project)
+	if err != nil {
+		return nil, errors.Wrap(err, "getting database engine")
+	}
+
+	return &DatabaseCredentials{
+		Engine:   engine.Name,
+		Version:  engine.Version,
+		Host:     "127.0.0.1",
+		Schema:   database.GetScheme(),
+		User:     database.GetUsername(),
+		Password: string(dbPassword),
+	}, nil
+}
 
+// addressPort returns the port of a host:port address.
+func addressPort(address string) (int, error) {
+	_, port, err := net.SplitHostPort(address)
 	if err != nil {
-		return errors.Wrap(err, "running port-forward")
+		return 0, errors.Wrapf(err, "parsing address %s", address)
 	}
 
-	return nil
+	return strconv.Atoi(port) //nolint:wrapcheck
 }
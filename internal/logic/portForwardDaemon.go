@@ -0,0 +1,405 @@
+package logic
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+const (
+	portForwardDaemonStartTimeout = time.Minute
+	portForwardDaemonPollInterval = 200 * time.Millisecond
+	portForwardDaemonLogTail      = 2048
+)
+
+// PortForwardState is written to the state directory by the background port-forward processes.
+type PortForwardState struct {
+	ID          string             `json:"id" yaml:"id"`
+	PID         int                `json:"pid" yaml:"pid"`
+	Context     string             `json:"context" yaml:"context"`
+	Environment string             `json:"environment" yaml:"environment"`
+	Services    []ForwardedService `json:"services" yaml:"services"`
+	LogFile     string             `json:"logFile" yaml:"logFile"`
+	StartedAt   time.Time          `json:"startedAt" yaml:"startedAt"`
+}
+
+// ForwardedService is a service forwarded by a background port-forward.
+type ForwardedService struct {
+	Service      string `json:"service" yaml:"service"`
+	LocalAddress string `json:"localAddress" yaml:"localAddress"`
+	RemotePort   int    `json:"remotePort" yaml:"remotePort"`
+}
+
+func (c *PortForwardClient) RunCmdPortForwardList(cmd *cobra.Command, args []string) error {
+	format := c.GetString("port_forward_list_output")
+	if err := checkOutputFormat(format, OutputFormatTable, OutputFormatJSON, OutputFormatYAML); err != nil {
+		return err
+	}
+
+	states, err := c.readPortForwardStates()
+	if err != nil {
+		return err
+	}
+
+	if isStructuredOutput(format) {
+		return printStructured(format, states)
+	}
+
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"ID", "Context", "Environment", "PID", "Services", "Started"})
+
+	for _, s := range states {
+		services := make([]string, 0, len(s.Services))
+		for _, f := range s.Services {
+			services = append(services, fmt.Sprintf("%s %s", f.Service, f.LocalAddress))
+		}
+
+		startedAt := s.StartedAt
+
+		t.AppendRow(table.Row{
+			s.ID, s.Context, s.Environment, s.PID, strings.Join(services, "\n"), formatTime(&startedAt),
+		})
+	}
+
+	t.Render()
+
+	return nil
+}
+
+func (c *PortForwardClient) RunCmdPortForwardStop(cmd *cobra.Command, args []string) error {
+	all := c.GetBool("port_forward_stop_all")
+	if all == (len(args) == 1) {
+		return errors.New("please specify the id of the port-forward or --all")
+	}
+
+	states, err := c.readPortForwardStates()
+	if err != nil {
+		return err
+	}
+
+	stopped := 0
+
+	for _, s := range states {
+		if !all && s.ID != args[0] {
+			continue
+		}
+
+		// The pid is only signalled while the process holds the lock of the port-forward, so a process which
+		// reused the pid of an exited port-forward is never stopped.
+		if c.portForwardDaemonAlive(s.ID) {
+			if err := stopProcess(s.PID); err != nil && c.portForwardDaemonAlive(s.ID) {
+				return errors.Wrapf(err, "stopping port-forward %s", s.ID)
+			}
+		}
+
+		c.removePortForwardState(s.ID)
+
+		log.Infof("Port-forward %s stopped", s.ID)
+
+		stopped++
+	}
+
+	if !all && stopped == 0 {
+		return errors.Errorf("cannot find port-forward %s", args[0])
+	}
+
+	return nil
+}
+
+// startPortForwardDaemon runs the current command again as a detached process and waits until it is forwarding. The
+// flags in drop are removed from the arguments of the process, e.g. the ones which print or write credentials.
+func (c *Client) startPortForwardDaemon(drop ...*pflag.Flag) (*PortForwardState, error) {
+	executable, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "getting the path of the executable")
+	}
+
+	if err := os.MkdirAll(c.portForwardStateDir(), 0o700); err != nil {
+		return nil, errors.Wrap(err, "creating port-forward state directory")
+	}
+
+	id := newPortForwardID()
+	logFile := filepath.Join(c.portForwardStateDir(), id+".log")
+
+	out, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating port-forward log file")
+	}
+	defer out.Close()
+
+	// The process does not start another one as it finds its id in the environment, see isPortForwardDaemon.
+	cmd := exec.Command(executable, removeFlags(os.Args[1:], drop...)...)
+	cmd.Env = append(os.Environ(), fmt.Sprintf("%s=%s", strings.ToUpper(c.portForwardIDKey()), id))
+	cmd.Stdout = out
+	cmd.Stderr = out
+	cmd.SysProcAttr = detachedProcAttr()
+
+	if err := cmd.Start(); err != nil {
+		return nil, errors.Wrap(err, "starting background port-forward")
+	}
+
+	exited := make(chan error, 1)
+
+	go func() {
+		exited <- cmd.Wait()
+	}()
+
+	timeout := time.After(portForwardDaemonStartTimeout)
+
+	for {
+		select {
+		case <-exited:
+			return nil, errors.Errorf("background port-forward exited: %s", logTail(logFile))
+		case <-timeout:
+			_ = stopProcess(cmd.Process.Pid)
+
+			return nil, errors.Errorf("background port-forward did not start in %s, see %s",
+				portForwardDaemonStartTimeout, logFile)
+		case <-time.After(portForwardDaemonPollInterval):
+		}
+
+		state, err := c.readPortForwardState(id)
+		if err == nil {
+			return state, nil
+		}
+	}
+}
+
+// isPortForwardDaemon reports whether the process was started by startPortForwardDaemon.
+func (c *Client) isPortForwardDaemon() bool {
+	return c.GetString(c.portForwardIDKey()) != ""
+}
+
+// removeFlags returns the command line arguments without the flags and their values.
+func removeFlags(args []string, flags ...*pflag.Flag) []string {
+	out := make([]string, 0, len(args))
+
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if arg == "--" {
+			return append(out, args[i:]...)
+		}
+
+		remove, skipValue := false, false
+
+		for _, f := range flags {
+			short := f.Shorthand != ""
+
+			switch {
+			case arg == "--"+f.Name, short && arg == "-"+f.Shorthand:
+				// Flags without an optional value (e.g. bool flags) take the next argument as value.
+				remove, skipValue = true, f.NoOptDefVal == ""
+			case strings.HasPrefix(arg, "--"+f.Name+"="), short && strings.HasPrefix(arg, "-"+f.Shorthand):
+				remove = true
+			}
+		}
+
+		if !remove {
+			out = append(out, arg)
+		} else if skipValue {
+			i++
+		}
+	}
+
+	return out
+}
+
+// recordPortForwardDaemon writes the state file of the background port-forward process. The returned function
+// removes it. It does nothing if the process was not started by startPortForwardDaemon.
+func (c *Client) recordPortForwardDaemon(ctx context.Context, target *kubeTarget, tunnels []*tunnel) (func(), error) {
+	id := c.GetString(c.portForwardIDKey())
+	if id == "" {
+		return func() {}, nil
+	}
+
+	ctx, err := c.prepareContext(ctx)
+	if err != nil {
+		return nil, errors.Wrap(err, "preparing context")
+	}
+
+	// The lock is held while the process is running and tells the other commands that the pid is still ours.
+	lock, err := os.OpenFile(c.portForwardLockPath(id), os.O_CREATE|os.O_RDWR, 0o600)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating port-forward lock")
+	}
+
+	if err := lockFile(lock); err != nil {
+		_ = lock.Close()
+
+		return nil, errors.Wrap(err, "locking port-forward")
+	}
+
+	state := PortForwardState{
+		ID:          id,
+		PID:         os.Getpid(),
+		Context:     c.getRcContext(ctx).Name,
+		Environment: target.Environment.GetName(),
+		LogFile:     filepath.Join(c.portForwardStateDir(), id+".log"),
+		StartedAt:   time.Now(),
+	}
+
+	for _, t := range tunnels {
+		state.Services = append(state.Services, ForwardedService{
+			Service:      t.Name,
+			LocalAddress: t.listener.Addr().String(),
+			RemotePort:   t.Service.Port,
+		})
+	}
+
+	b, err := json.Marshal(state)
+	if err != nil {
+		_ = lock.Close()
+
+		return nil, errors.Wrap(err, "encoding port-forward state")
+	}
+
+	if err := writeFileAtomic(c.portForwardStatePath(id), b); err != nil {
+		_ = lock.Close()
+
+		return nil, errors.Wrap(err, "writing port-forward state")
+	}
+
+	return func() {
+		_ = lock.Close()
+
+		c.removePortForwardState(id)
+	}, nil
+}
+
+// portForwardDaemonAlive reports whether the background port-forward is running, i.e. its process holds the lock of
+// the port-forward. Unlike checking the pid, this is not fooled by another process reusing the pid.
+func (c *Client) portForwardDaemonAlive(id string) bool {
+	f, err := os.Open(c.portForwardLockPath(id))
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	return lockFile(f) != nil
+}
+
+// readPortForwardStates returns the background port-forwards. The states of the processes which are not running
+// anymore are removed.
+func (c *Client) readPortForwardStates() ([]PortForwardState, error) {
+	files, err := filepath.Glob(filepath.Join(c.portForwardStateDir(), "*.json"))
+	if err != nil {
+		return nil, errors.Wrap(err, "listing port-forward states")
+	}
+
+	states := make([]PortForwardState, 0, len(files))
+
+	for _, f := range files {
+		id := strings.TrimSuffix(filepath.Base(f), ".json")
+
+		state, err := c.readPortForwardState(id)
+		if err != nil {
+			log.Debugf("Ignoring port-forward state %s: %s", f, err)
+
+			continue
+		}
+
+		if !c.portForwardDaemonAlive(id) {
+			log.Debugf("Removing stale port-forward %s", id)
+			c.removePortForwardState(id)
+
+			continue
+		}
+
+		states = append(states, *state)
+	}
+
+	sort.Slice(states, func(i, j int) bool {
+		return states[i].StartedAt.Before(states[j].StartedAt)
+	})
+
+	return states, nil
+}
+
+func (c *Client) readPortForwardState(id string) (*PortForwardState, error) {
+	b, err := os.ReadFile(c.portForwardStatePath(id))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading port-forward state")
+	}
+
+	state := &PortForwardState{}
+	if err := json.Unmarshal(b, state); err != nil {
+		return nil, errors.Wrap(err, "decoding port-forward state")
+	}
+
+	return state, nil
+}
+
+func (c *Client) removePortForwardState(id string) {
+	_ = os.Remove(c.portForwardStatePath(id))
+	_ = os.Remove(filepath.Join(c.portForwardStateDir(), id+".log"))
+	_ = os.Remove(c.portForwardLockPath(id))
+}
+
+func (c *Client) portForwardStateDir() string {
+	return filepath.Join(c.CacheDir(), "port-forward")
+}
+
+func (c *Client) portForwardStatePath(id string) string {
+	return filepath.Join(c.portForwardStateDir(), id+".json")
+}
+
+func (c *Client) portForwardLockPath(id string) string {
+	return filepath.Join(c.portForwardStateDir(), id+".lock")
+}
+
+// portForwardIDKey is the config key of the id of the background port-forward. It is passed to the background
+// process in the environment.
+func (c *Client) portForwardIDKey() string {
+	return fmt.Sprintf("%s_port_forward_id", c.ConfigPrefix())
+}
+
+// printPortForwardDaemon prints how to reach and stop the background port-forward.
+func printPortForwardDaemon(state *PortForwardState, appName string) {
+	t := NewTableWriter()
+	t.AppendHeader(table.Row{"Service", "Local Address", "Remote Port"})
+
+	for _, s := range state.Services {
+		t.AppendRow(table.Row{s.Service, s.LocalAddress, s.RemotePort})
+	}
+
+	t.Render()
+
+	log.Infof("Port-forward %s is running in the background (pid %d), stop it using: %s port-forward stop %s",
+		state.ID, state.PID, appName, state.ID)
+}
+
+func newPortForwardID() string {
+	b := make([]byte, 4) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
+
+// logTail returns the end of the log file of a background port-forward.
+func logTail(file string) string {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err.Error()
+	}
+
+	if len(b) > portForwardDaemonLogTail {
+		b = b[len(b)-portForwardDaemonLogTail:]
+	}
+
+	return string(bytes.TrimSpace(b))
+}
@@ -0,0 +1,88 @@
+package logic
+
+import (
+	"os"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/suite"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type PortForwardDaemonTestSuite struct {
+	suite.Suite
+}
+
+func TestPortForwardDaemonTestSuite(t *testing.T) {
+	suite.Run(t, new(PortForwardDaemonTestSuite))
+}
+
+func (suite *PortForwardDaemonTestSuite) TestRemoveFlags() {
+	flags := pflag.NewFlagSet("db", pflag.ContinueOnError)
+	flags.StringP("output", "o", "table", "")
+	flags.String("write-config", "", "")
+	flags.Bool("force", false, "")
+
+	drop := []*pflag.Flag{flags.Lookup("output"), flags.Lookup("write-config"), flags.Lookup("force")}
+
+	tests := []struct {
+		name string
+		args []string
+		want []string
+	}{
+		{
+			name: "without flags",
+			args: []string{"port-forward", "db", "redis", "--background=1"},
+			want: []string{"port-forward", "db", "redis", "--background=1"},
+		},
+		{
+			name: "values in the next argument",
+			args: []string{"port-forward", "db", "--write-config", ".env", "-o", "json", "--background"},
+			want: []string{"port-forward", "db", "--background"},
+		},
+		{
+			name: "values in the argument",
+			args: []string{"port-forward", "db", "--write-config=.env", "-ojson", "--output=yaml", "redis"},
+			want: []string{"port-forward", "db", "redis"},
+		},
+		{
+			name: "bool flag",
+			args: []string{"port-forward", "db", "--force", "redis", "--force=false"},
+			want: []string{"port-forward", "db", "redis"},
+		},
+		{
+			name: "after the end of the flags",
+			args: []string{"port-forward", "db", "--", "--force"},
+			want: []string{"port-forward", "db", "--", "--force"},
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			suite.Equal(tt.want, removeFlags(tt.args, drop...))
+		})
+	}
+}
+
+func (suite *PortForwardDaemonTestSuite) TestPortForwardDaemonAlive() {
+	app := config.New("cloud", "reward", "v0.0.1")
+	app.Set(app.ConfigPrefix()+"_cache_dir", suite.T().TempDir())
+
+	c := New(app)
+	suite.Require().NoError(os.MkdirAll(c.portForwardStateDir(), 0o700))
+
+	suite.False(c.portForwardDaemonAlive("missing"))
+
+	// A lock file which is not locked belongs to an exited process, even if its pid is in use.
+	suite.Require().NoError(os.WriteFile(c.portForwardLockPath("exited"), nil, 0o600))
+	suite.False(c.portForwardDaemonAlive("exited"))
+
+	lock, err := os.OpenFile(c.portForwardLockPath("running"), os.O_CREATE|os.O_RDWR, 0o600)
+	suite.Require().NoError(err)
+	suite.Require().NoError(lockFile(lock))
+	suite.True(c.portForwardDaemonAlive("running"))
+
+	suite.Require().NoError(lock.Close())
+	suite.False(c.portForwardDaemonAlive("running"))
+}
@@ -0,0 +1,29 @@
+//go:build !windows
+
+package logic
+
+import (
+	"os"
+	"syscall"
+)
+
+// detachedProcAttr starts the process in a new session, so it keeps running after the terminal is closed.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
+
+// lockFile locks the file exclusively without waiting. The lock is released when the file is closed or the process
+// exits.
+func lockFile(f *os.File) error {
+	return syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB) //nolint:wrapcheck
+}
+
+// stopProcess asks the process with the pid to terminate.
+func stopProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return p.Signal(syscall.SIGTERM) //nolint:wrapcheck
+}
@@ -0,0 +1,39 @@
+//go:build windows
+
+package logic
+
+import (
+	"os"
+	"syscall"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	createNewProcessGroup = 0x00000200
+	detachedProcess       = 0x00000008
+)
+
+// detachedProcAttr starts the process without a console, so it keeps running after the terminal is closed.
+func detachedProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{CreationFlags: createNewProcessGroup | detachedProcess}
+}
+
+// lockFile locks the file exclusively without waiting. The lock is released when the file is closed or the process
+// exits.
+func lockFile(f *os.File) error {
+	return windows.LockFileEx( //nolint:wrapcheck
+		windows.Handle(f.Fd()), windows.LOCKFILE_EXCLUSIVE_LOCK|windows.LOCKFILE_FAIL_IMMEDIATELY, 0, 1, 0,
+		&windows.Overlapped{},
+	)
+}
+
+// stopProcess terminates the process with the pid.
+func stopProcess(pid int) error {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return err //nolint:wrapcheck
+	}
+
+	return p.Kill() //nolint:wrapcheck
+}
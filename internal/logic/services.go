@@ -0,0 +1,130 @@
+package logic
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"github.com/pkg/errors"
+)
+
+// ForwardService describes a service of the environment which can be port-forwarded.
+type ForwardService struct {
+	Component string `mapstructure:"component" json:"component" yaml:"component"`
+	Port      int    `mapstructure:"port" json:"port" yaml:"port"`
+}
+
+// defaultForwardServices is the built-in service catalog. The db service is forwarded to the port of the database
+// engine of the project. The catalog can be extended and overridden using the port_forward_services key of the
+// config file, e.g.:
+//
+//	port_forward_services:
+//	  redis:
+//	    component: redis
+//	    port: 6380
+var defaultForwardServices = map[string]ForwardService{
+	"db":                  {Component: ComponentDB, Port: 3306},
+	"redis":               {Component: "redis", Port: 6379},
+	"opensearch":          {Component: "opensearch", Port: 9200},
+	"elasticsearch":       {Component: "elasticsearch", Port: 9200},
+	"rabbitmq":            {Component: "rabbitmq", Port: 5672},
+	"rabbitmq-management": {Component: "rabbitmq", Port: 15672},
+	"varnish":             {Component: "varnish", Port: 80},
+	"mailhog":             {Component: "mailhog", Port: 8025},
+	"web":                 {Component: ComponentMain, Port: 80},
+}
+
+// ForwardServiceOutput is the machine-readable representation of a service in the catalog.
+type ForwardServiceOutput struct {
+	Name      string `json:"name" yaml:"name"`
+	Component string `json:"component" yaml:"component"`
+	Port      int    `json:"port" yaml:"port"`
+	Pods      int    `json:"pods" yaml:"pods"`
+}
+
+// getForwardServices returns the built-in service catalog merged with the services configured in the config file.
+func (c *Client) getForwardServices() (map[string]ForwardService, error) {
+	configured := make(map[string]ForwardService)
+
+	if err := c.UnmarshalKey("port_forward_services", &configured); err != nil {
+		return nil, errors.Wrap(err, "reading port-forward services")
+	}
+
+	services := make(map[string]ForwardService, len(defaultForwardServices)+len(configured))
+	for name, s := range defaultForwardServices {
+		services[name] = s
+	}
+
+	for name, s := range configured {
+		if s.Component == "" || s.Port <= 0 || s.Port > 65535 {
+			return nil, errors.Errorf("port-forward service %q needs a component and a valid port", name)
+		}
+
+		services[strings.ToLower(name)] = s
+	}
+
+	return services, nil
+}
+
+// getForwardService returns the service called name from the catalog.
+func (c *Client) getForwardService(name string) (ForwardService, error) {
+	services, err := c.getForwardServices()
+	if err != nil {
+		return ForwardService{}, err
+	}
+
+	s, ok := services[strings.ToLower(name)]
+	if !ok {
+		return ForwardService{}, errors.Errorf("unknown service %q, use --list to show the available services", name)
+	}
+
+	return s, nil
+}
+
+// listForwardServices prints the service catalog with the number of pods running each service in the environment.
+func (c *Client) listForwardServices(ctx context.Context, t *kubeTarget, format string) error {
+	services, err := c.getForwardServices()
+	if err != nil {
+		return err
+	}
+
+	pods, err := c.listPods(ctx, t, "")
+	if err != nil {
+		return errors.Wrap(err, "listing pods")
+	}
+
+	counts := make(map[string]int)
+	for _, p := range pods {
+		counts[p.Labels[componentLabel]]++
+	}
+
+	out := make([]ForwardServiceOutput, 0, len(services))
+	for name, s := range services {
+		out = append(out, ForwardServiceOutput{Name: name, Component: s.Component, Port: s.Port, Pods: counts[s.Component]})
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	if isStructuredOutput(format) {
+		return printStructured(format, out)
+	}
+
+	tw := NewTableWriter()
+	tw.AppendHeader(table.Row{"Service", "Component", "Remote Port", "Available"})
+
+	for _, s := range out {
+		available := "no"
+		if s.Pods > 0 {
+			available = "yes"
+		}
+
+		tw.AppendRow(table.Row{s.Name, s.Component, s.Port, available})
+	}
+
+	tw.Render()
+
+	return nil
+}
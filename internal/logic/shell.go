@@ -2,18 +2,31 @@ package logic
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"path/filepath"
+	"os"
+	"strconv"
 	"strings"
 
+	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/pkg/errors"
 	"github.com/rewardenv/reward-cloud-cli/internal/config"
-	"github.com/rewardenv/reward-cloud-cli/internal/shell"
+	"github.com/rewardenv/reward-cloud-cli/internal/kube"
+	log "github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
-	corev1 "golang.org/x/build/kubernetes/api"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
 )
 
+// ExitCodeError is returned when a remote command exits with a non-zero exit code. The CLI exits with the same
+// code.
+type ExitCodeError struct {
+	Code int
+}
+
+func (e *ExitCodeError) Error() string {
+	return fmt.Sprintf("command exited with code %d", e.Code)
+}
+
 type ShellClient struct {
 	*Client
 }
@@ -22,98 +35,216 @@ func NewShellClient(c *config.App) *ShellClient {
 	return &ShellClient{New(c)}
 }
 
+// RunCmdShell opens an interactive shell in the environment or, if args are given, runs them as a command.
 func (c *ShellClient) RunCmdShell(cmd *cobra.Command, args []string) error {
-	err := c.CheckKubectl()
-	if err != nil {
-		return errors.Wrap(err, "checking kubectl")
-	}
+	return c.runShell(cmd.Context(), args, "shell")
+}
 
-	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(context.Background())
+// RunCmdExec runs args as a command in the environment.
+func (c *ShellClient) RunCmdExec(cmd *cobra.Command, args []string) error {
+	return c.runShell(cmd.Context(), args, "exec")
+}
+
+// runShell runs the command (or an interactive shell if it is empty) in the selected pod and container. A TTY is
+// only allocated if the standard input is a terminal, so the command can be used in scripts. The flags are read
+// using the keyPrefix of the command.
+func (c *ShellClient) runShell(ctx context.Context, command []string, keyPrefix string) error {
+	ctx, err := NewLoginClient(c.App).CheckTokenAndLogin(ctx)
 	if err != nil {
 		return errors.Wrap(err, "logging in")
 	}
 
-	project, err := c.getProject(ctx)
+	target, err := c.prepareKubeTarget(ctx)
 	if err != nil {
-		return errors.Wrap(err, "getting project")
+		return err
 	}
 
-	projectTypeVersion, _, err := c.RewardCloud.ProjectTypeVersionApi.ApiProjectTypeVersionsIdGet(
-		ctx, GetIDFromPath(project.GetProjectTypeVersion())).Execute()
+	component := c.GetString(keyPrefix + "_component")
+
+	pod, err := c.selectPod(ctx, target, component, c.GetString(keyPrefix+"_pod"))
 	if err != nil {
-		return errors.Wrap(err, "getting project type version")
+		return errors.Wrap(err, "getting shell pod")
 	}
 
-	projectType, _, err := c.RewardCloud.ProjectTypeApi.ApiProjectTypesIdGet(
-		ctx, GetIDFromPath(projectTypeVersion.GetProjectType())).Execute()
-	if err != nil {
-		return errors.Wrap(err, "getting project type")
+	// The container of the main component is named after the project type.
+	defaultContainer := component
+	if component == ComponentMain {
+		projectType, err := c.getProjectType(ctx, target.Project)
+		if err != nil {
+			return errors.Wrap(err, "getting project type")
+		}
+
+		defaultContainer = strings.ToLower(projectType.GetName())
 	}
 
-	environment, err := c.getEnvironment(ctx)
+	container, err := selectContainer(pod, c.GetString(keyPrefix+"_container"), defaultContainer)
 	if err != nil {
-		return errors.Wrap(err, "getting environment")
+		return err
+	}
+
+	if len(command) == 0 {
+		command = []string{"sh", "-c", "bash || sh"}
+	}
+
+	err = target.Kube.Exec(ctx, pod.Name, kube.ExecOptions{
+		Container: container,
+		Command:   remoteCommand(command, c.GetString(keyPrefix+"_user"), c.GetString(keyPrefix+"_workdir")),
+		Stdin:     os.Stdin,
+		Stdout:    os.Stdout,
+		Stderr:    os.Stderr,
+		TTY:       term.IsTerminal(int(os.Stdin.Fd())),
+	})
+	if code, ok := kube.ExitCode(err); ok {
+		return &ExitCodeError{Code: code}
 	}
 
-	cluster, err := c.getCluster(ctx)
 	if err != nil {
-		return errors.Wrap(err, "getting cluster")
+		return errors.Wrap(err, "running shell")
+	}
+
+	return nil
+}
+
+// selectPod returns the pod with the given name or the pod of the component. If the component has several pods,
+// the user is asked to pick one.
+func (c *Client) selectPod(ctx context.Context, t *kubeTarget, component, name string) (*corev1.Pod, error) {
+	if name != "" {
+		component = ""
 	}
 
-	kubeconfigFile, err := c.prepareKubeconfig(cluster)
+	pods, err := c.listPods(ctx, t, component)
 	if err != nil {
-		return errors.Wrap(err, "preparing kubeconfig")
-	}
-
-	out, err := c.Kubectl.RunCommand([]string{
-		"--kubeconfig", kubeconfigFile.Name(),
-		"--cache-dir", filepath.Join(c.CacheDir(), "kubectl"),
-		"get",
-		"pod",
-		"-n", fmt.Sprintf("%s-%s", project.GetCodeName(), environment.GetCodeName()),
-		"--selector", "reward.itg.cloud/component=main",
-		"-o", "json",
-	},
-		shell.WithSuppressOutput(true), shell.WithCatchOutput(true))
+		return nil, err
+	}
+
+	if name != "" {
+		for i := range pods {
+			if pods[i].Name == name {
+				return &pods[i], nil
+			}
+		}
+
+		return nil, errors.Errorf("cannot find pod %s", name)
+	}
+
+	names := make([]string, 0, len(pods))
+	for _, p := range pods {
+		names = append(names, p.Name)
+	}
+
+	switch {
+	case len(pods) == 0:
+		return nil, errors.Errorf("cannot find %s pod", component)
+	case len(pods) == 1:
+		return &pods[0], nil
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		return nil, errors.Errorf("found %d %s pods, please select one using --pod (%s)",
+			len(pods), component, strings.Join(names, ", "))
+	}
+
+	i, err := pick(fmt.Sprintf("Select a %s pod...", component), []string{"Pod", "Phase"}, len(pods),
+		func(i int) []interface{} { return []interface{}{pods[i].Name, pods[i].Status.Phase} })
 	if err != nil {
-		return errors.Wrapf(err, "running kubectl command, command output: %s", string(out))
+		return nil, errors.Wrap(err, "selecting pod")
+	}
+
+	return &pods[i], nil
+}
+
+// selectContainer returns the given container after checking that it exists in the pod. Without a given
+// container, the only container of the pod or defaultContainer is returned if possible, otherwise the user is
+// asked to pick one.
+func selectContainer(pod *corev1.Pod, container, defaultContainer string) (string, error) {
+	names := make([]string, 0, len(pod.Spec.Containers))
+	for _, ct := range pod.Spec.Containers {
+		if container == "" && ct.Name == defaultContainer {
+			return ct.Name, nil
+		}
+
+		if ct.Name == container {
+			return ct.Name, nil
+		}
+
+		names = append(names, ct.Name)
 	}
 
-	// Remove "Opening in existing browser session.\n" from output
-	if strings.Contains(string(out), "Opening in existing browser session.") {
-		newout := strings.TrimPrefix(string(out), "Opening in existing browser session.\n")
-		out = []byte(newout)
+	switch {
+	case container != "":
+		return "", errors.Errorf("cannot find container %s in pod %s (containers: %s)",
+			container, pod.Name, strings.Join(names, ", "))
+	case len(names) == 1:
+		return names[0], nil
+	case !term.IsTerminal(int(os.Stdin.Fd())):
+		return "", errors.Errorf("pod %s has %d containers, please select one using --container (%s)",
+			pod.Name, len(names), strings.Join(names, ", "))
 	}
 
-	var obj corev1.PodList
-	err = json.Unmarshal(out, &obj)
+	i, err := pick("Select a container...", []string{"Container"}, len(names),
+		func(i int) []interface{} { return []interface{}{names[i]} })
 	if err != nil {
-		return errors.Wrapf(err, "unmarshalling pod list, command output: %s", string(out))
+		return "", errors.Wrap(err, "selecting container")
 	}
 
-	if len(obj.Items) != 1 {
-		return errors.Errorf("cannot find shell pod, command output: %s", string(out))
+	return names[i], nil
+}
+
+// pick lists n items in a numbered table and returns the index of the item selected by the user.
+func pick(msg string, header []string, n int, row func(i int) []interface{}) (int, error) {
+	log.Info(msg)
+
+	t := NewTableWriter()
+
+	h := table.Row{"#"}
+	for _, col := range header {
+		h = append(h, col)
 	}
 
-	podname := obj.Items[0].Name
+	t.AppendHeader(h)
+
+	for i := 0; i < n; i++ {
+		t.AppendRow(append(table.Row{i + 1}, row(i)...))
+	}
 
-	_, err = c.Kubectl.RunCommand([]string{
-		"--kubeconfig", kubeconfigFile.Name(),
-		"--cache-dir", filepath.Join(c.CacheDir(), "kubectl"),
-		"exec",
-		"-i",
-		"-t",
-		"-n", fmt.Sprintf("%s-%s", project.GetCodeName(), environment.GetCodeName()),
-		podname,
-		"-c", strings.ToLower(projectType.GetName()),
-		"--",
-		"sh", "-c", "bash || sh",
-	},
-		shell.WithSuppressOutput(false), shell.WithCatchOutput(false))
+	t.Render()
 
+	val, err := GetValueFromPrompt("Enter the number", WithMinimumValue(1), WithMaximumValue(n))
 	if err != nil {
-		return errors.Wrap(err, "running shell")
+		return 0, errors.Wrap(err, "getting number")
 	}
 
-	return nil
+	i, err := strconv.Atoi(val)
+	if err != nil {
+		return 0, errors.Wrap(err, "parsing number")
+	}
+
+	return i - 1, nil
+}
+
+// remoteCommand wraps the command to run it in workdir as user. Running as another user requires su in the
+// container and a container running as root.
+func remoteCommand(command []string, user, workdir string) []string {
+	if user == "" && workdir == "" {
+		return command
+	}
+
+	quoted := make([]string, 0, len(command))
+	for _, arg := range command {
+		quoted = append(quoted, shellQuote(arg))
+	}
+
+	script := "exec " + strings.Join(quoted, " ")
+	if workdir != "" {
+		script = "cd " + shellQuote(workdir) + " && " + script
+	}
+
+	if user == "" {
+		return []string{"sh", "-c", script}
+	}
+
+	return []string{"su", "-s", "/bin/sh", "-c", script, user}
+}
+
+// shellQuote quotes s for POSIX shells.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
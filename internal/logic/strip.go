@@ -0,0 +1,105 @@
+package logic
+
+import (
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/strip"
+)
+
+// getStripProfile returns the strip profile configured under strip_profiles in the config file.
+// It returns nil if name is empty.
+func (c *Client) getStripProfile(name string) (*strip.Profile, error) {
+	if name == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	profiles := make(map[string]*strip.Profile)
+
+	if err := c.UnmarshalKey("strip_profiles", &profiles); err != nil {
+		return nil, errors.Wrap(err, "reading strip profiles")
+	}
+
+	profile, ok := profiles[name]
+	if !ok || profile == nil {
+		return nil, errors.Errorf("strip profile %q is not defined in the config file", name)
+	}
+
+	if err := profile.Validate(); err != nil {
+		return nil, errors.Wrapf(err, "validating strip profile %q", name)
+	}
+
+	return profile, nil
+}
+
+// stripDump applies the strip profile to the .sql or .sql.gz dump in place.
+func stripDump(ctx context.Context, file, name string, profile *strip.Profile) error {
+	gzipped := strings.HasSuffix(file, ".sql.gz")
+	if !gzipped && !strings.HasSuffix(file, ".sql") {
+		return errors.Errorf("strip profiles can only be applied to database dumps (.sql, .sql.gz): %s", file)
+	}
+
+	tmp := file + ".strip"
+
+	err := streamToCommand(ctx, fmt.Sprintf("Applying strip profile %s to %s...", name, filepath.Base(file)),
+		openFile(file), nil,
+		func(_ context.Context, r io.Reader) ([]byte, error) {
+			return nil, rewriteDump(r, tmp, gzipped, profile)
+		},
+	)
+	if err != nil {
+		_ = os.Remove(tmp)
+
+		return errors.Wrap(err, "applying strip profile")
+	}
+
+	if err := os.Rename(tmp, file); err != nil {
+		return errors.Wrap(err, "replacing dump")
+	}
+
+	log.Infof("Strip profile %s applied to %s", name, file)
+
+	return nil
+}
+
+func rewriteDump(r io.Reader, dest string, gzipped bool, profile *strip.Profile) error {
+	f, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o600)
+	if err != nil {
+		return errors.Wrap(err, "creating file")
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+
+	if gzipped {
+		zr, err := gunzip(r)
+		if err != nil {
+			return err
+		}
+
+		zw := gzip.NewWriter(f)
+		defer zw.Close()
+
+		r, w = zr, zw
+	}
+
+	if err := strip.Rewrite(w, r, profile); err != nil {
+		return errors.Wrap(err, "rewriting dump")
+	}
+
+	if zw, ok := w.(*gzip.Writer); ok {
+		if err := zw.Close(); err != nil {
+			return errors.Wrap(err, "compressing dump")
+		}
+	}
+
+	return errors.Wrap(f.Close(), "closing file")
+}
@@ -0,0 +1,83 @@
+package logic
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/ui"
+)
+
+// ErrTransferAborted is returned when the user quits a running transfer.
+var ErrTransferAborted = errors.New("transfer aborted")
+
+// runWithProgress runs fn while it renders a progress bar. The function reports the number of bytes transferred
+// using the report callback. The context passed to fn is canceled if the user quits.
+func runWithProgress(
+	ctx context.Context, msg string, fn func(ctx context.Context, report func(written, total int64)) error,
+) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	p := tea.NewProgram(ui.NewProgressModel(msg))
+
+	var finished int32
+
+	var g errgroup.Group
+	g.Go(func() error {
+		defer p.Send(ui.ResultMsg{Ready: true})
+		defer atomic.StoreInt32(&finished, 1)
+
+		return fn(ctx, func(written, total int64) {
+			p.Send(ui.ProgressMsg{Written: written, Total: total})
+		})
+	})
+
+	if _, err := p.Run(); err != nil {
+		cancel()
+		_ = g.Wait()
+
+		return errors.Wrap(err, "running progress ui")
+	}
+
+	// The UI quits before fn finished only if the user quit it.
+	aborted := atomic.LoadInt32(&finished) == 0
+
+	cancel()
+
+	err := g.Wait()
+	if aborted || errors.Is(err, context.Canceled) {
+		return ErrTransferAborted
+	}
+
+	return err
+}
+
+// progressReader counts the bytes read from the underlying reader and reports them.
+type progressReader struct {
+	r      io.Reader
+	read   int64
+	total  int64
+	report func(written, total int64)
+}
+
+func newProgressReader(r io.Reader, total int64, report func(written, total int64)) *progressReader {
+	return &progressReader{
+		r:      r,
+		total:  total,
+		report: report,
+	}
+}
+
+func (p *progressReader) Read(b []byte) (int, error) {
+	n, err := p.r.Read(b)
+	if n > 0 && p.report != nil {
+		p.report(atomic.AddInt64(&p.read, int64(n)), p.total)
+	}
+
+	return n, err //nolint:wrapcheck
+}
@@ -0,0 +1,488 @@
+package logic
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	btable "github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/term"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/ui"
+)
+
+const (
+	TunnelStateConnecting   = "connecting"
+	TunnelStateConnected    = "connected"
+	TunnelStateReconnecting = "reconnecting"
+)
+
+const (
+	tunnelMinBackoff       = time.Second
+	tunnelMaxBackoff       = 30 * time.Second
+	tunnelPodCheckInterval = 5 * time.Second
+	tunnelRefreshInterval  = 500 * time.Millisecond
+)
+
+// localPortAuto is the local port of the tunnels which use a free port if the remote port is taken.
+const localPortAuto = 0
+
+// tunnelSpec is a service to forward to a local port.
+type tunnelSpec struct {
+	Name      string
+	Service   ForwardService
+	LocalPort int
+	// explicitLocalPort is set if the local port was given instead of following the remote port.
+	explicitLocalPort bool
+}
+
+// TunnelStatus is the machine-readable representation of a tunnel.
+type TunnelStatus struct {
+	Service      string `json:"service" yaml:"service"`
+	LocalAddress string `json:"localAddress" yaml:"localAddress"`
+	RemotePort   int    `json:"remotePort" yaml:"remotePort"`
+	Pod          string `json:"pod" yaml:"pod"`
+	State        string `json:"state" yaml:"state"`
+	Active       int    `json:"activeConnections" yaml:"activeConnections"`
+	Total        int    `json:"totalConnections" yaml:"totalConnections"`
+}
+
+// tunnel accepts the connections on the local port and proxies them to the port-forward of the current pod of the
+// service. The local port stays open while the port-forward is reconnected.
+type tunnel struct {
+	tunnelSpec
+	listener net.Listener
+
+	mu      sync.Mutex
+	pod     string
+	state   string
+	backend string
+	active  int
+	total   int
+}
+
+// parseTunnelSpecs parses the service[:local port] arguments. Without a local port the remote port of the service
+// is used. The local port "auto" selects a free port if the remote port is taken.
+func (c *Client) parseTunnelSpecs(args []string) ([]tunnelSpec, error) {
+	specs := make([]tunnelSpec, 0, len(args))
+	seen := make(map[int]string, len(args))
+
+	for _, arg := range args {
+		name, port, hasPort := strings.Cut(arg, ":")
+
+		service, err := c.getForwardService(name)
+		if err != nil {
+			return nil, err
+		}
+
+		spec := tunnelSpec{Name: name, Service: service, LocalPort: service.Port}
+
+		if hasPort {
+			spec.explicitLocalPort = true
+
+			spec.LocalPort, err = parseLocalPort(port)
+			if err != nil {
+				return nil, errors.Wrapf(err, "parsing %q", arg)
+			}
+		}
+
+		if other, ok := seen[spec.LocalPort]; ok && spec.LocalPort != localPortAuto {
+			return nil, errors.Errorf("%s and %s use the same local port %d, set another one using %s:<port>",
+				other, name, spec.LocalPort, name)
+		}
+
+		seen[spec.LocalPort] = name
+		specs = append(specs, spec)
+	}
+
+	return specs, nil
+}
+
+// parseLocalPort parses a port number or "auto", which is returned as localPortAuto.
+func parseLocalPort(s string) (int, error) {
+	if strings.EqualFold(s, "auto") {
+		return localPortAuto, nil
+	}
+
+	port, err := strconv.Atoi(s)
+	if err != nil || port <= 0 || port > 65535 {
+		return 0, errors.Errorf("invalid local port %q, use a port number or auto", s)
+	}
+
+	return port, nil
+}
+
+// listenTunnels opens the local ports of the tunnels, so conflicts are detected before connecting to the cluster.
+// Tunnels with an automatic local port use the remote port of the service if it is free or a random free port.
+func listenTunnels(specs []tunnelSpec) ([]*tunnel, error) {
+	tunnels := make([]*tunnel, 0, len(specs))
+
+	for _, spec := range specs {
+		l, err := listenLocalPort(spec)
+		if err != nil {
+			closeTunnels(tunnels)
+
+			return nil, err
+		}
+
+		spec.LocalPort = l.Addr().(*net.TCPAddr).Port
+
+		tunnels = append(tunnels, &tunnel{tunnelSpec: spec, listener: l, state: TunnelStateConnecting})
+	}
+
+	return tunnels, nil
+}
+
+func listenLocalPort(spec tunnelSpec) (net.Listener, error) {
+	port := spec.LocalPort
+	if port == localPortAuto {
+		port = spec.Service.Port
+	}
+
+	l, err := net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+	if err == nil {
+		return l, nil
+	}
+
+	if spec.LocalPort != localPortAuto {
+		return nil, errors.Errorf("local port %d for %s is not available: %s, choose another port or use auto",
+			port, spec.Name, err)
+	}
+
+	l, err = net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.Wrapf(err, "listening on a free local port for %s", spec.Name)
+	}
+
+	log.Infof("Local port %d is in use, forwarding %s on port %d", port, spec.Name, l.Addr().(*net.TCPAddr).Port)
+
+	return l, nil
+}
+
+func closeTunnels(tunnels []*tunnel) {
+	for _, t := range tunnels {
+		_ = t.listener.Close()
+	}
+}
+
+// runTunnels forwards the services until the context is canceled or the user quits the status table. Broken
+// port-forwards are reconnected with backoff and the pods are looked up again, so rollouts are followed. The
+// status table is only shown if interactive is set, otherwise the events are logged.
+func (c *Client) runTunnels(ctx context.Context, target *kubeTarget, tunnels []*tunnel, interactive bool) error {
+	defer closeTunnels(tunnels)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events := make(chan string, 64) //nolint:gomnd
+
+	var g errgroup.Group
+
+	for _, t := range tunnels {
+		t := t
+
+		g.Go(func() error {
+			t.serve(ctx)
+
+			return nil
+		})
+		g.Go(func() error {
+			c.superviseTunnel(ctx, target, t, events)
+
+			return nil
+		})
+	}
+
+	var err error
+	if interactive && term.IsTerminal(int(os.Stdout.Fd())) {
+		err = showTunnels(ctx, tunnels, events)
+	} else {
+		logTunnels(ctx, events)
+	}
+
+	cancel()
+	closeTunnels(tunnels)
+
+	_ = g.Wait()
+
+	return err
+}
+
+// superviseTunnel keeps the port-forward of the tunnel running until the context is canceled.
+func (c *Client) superviseTunnel(ctx context.Context, target *kubeTarget, t *tunnel, events chan<- string) {
+	backoff := tunnelMinBackoff
+
+	for {
+		started := time.Now()
+
+		err := c.forwardTunnel(ctx, target, t, events)
+		if ctx.Err() != nil {
+			return
+		}
+
+		if time.Since(started) > tunnelMaxBackoff {
+			backoff = tunnelMinBackoff
+		}
+
+		t.setState(TunnelStateReconnecting, "")
+		sendEvent(events, fmt.Sprintf("%s: %s, reconnecting in %s", t.Name, err, backoff))
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+		if backoff > tunnelMaxBackoff {
+			backoff = tunnelMaxBackoff
+		}
+	}
+}
+
+// forwardTunnel looks up the pod of the service and forwards a random local port to it. It returns when the
+// connection is lost or the pod is gone.
+func (c *Client) forwardTunnel(ctx context.Context, target *kubeTarget, t *tunnel, events chan<- string) error {
+	pod, err := c.getPodName(ctx, target, t.Service.Component)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	ready := make(chan struct{})
+
+	fw, err := target.Kube.PortForwarder(ctx, pod, []string{fmt.Sprintf("0:%d", t.Service.Port)}, ready,
+		io.Discard, io.Discard)
+	if err != nil {
+		return errors.Wrap(err, "creating port-forward")
+	}
+
+	done := make(chan error, 1)
+
+	go func() {
+		done <- fw.ForwardPorts()
+	}()
+
+	select {
+	case err := <-done:
+		return errors.Wrap(err, "starting port-forward")
+	case <-ready:
+	}
+
+	ports, err := fw.GetPorts()
+	if err != nil || len(ports) == 0 {
+		return errors.Wrap(err, "getting forwarded port")
+	}
+
+	t.setState(TunnelStateConnected, pod)
+	t.setBackend(fmt.Sprintf("127.0.0.1:%d", ports[0].Local))
+
+	defer t.setBackend("")
+
+	sendEvent(events, fmt.Sprintf("%s: forwarding %s to pod %s port %d", t.Name, t.listener.Addr(), pod,
+		t.Service.Port))
+
+	ticker := time.NewTicker(tunnelPodCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case err := <-done:
+			if err == nil {
+				err = errors.New("port-forward stopped")
+			}
+
+			return errors.Wrap(err, "port-forward")
+		case <-ticker.C:
+			p, err := target.Kube.GetPod(ctx, pod)
+			if err != nil {
+				return errors.Wrapf(err, "checking pod %s", pod)
+			}
+
+			if p.DeletionTimestamp != nil || p.Status.Phase != corev1.PodRunning {
+				return errors.Errorf("pod %s is going away", pod)
+			}
+		}
+	}
+}
+
+// serve accepts the local connections until the context is canceled.
+func (t *tunnel) serve(ctx context.Context) {
+	go func() {
+		<-ctx.Done()
+		_ = t.listener.Close()
+	}()
+
+	for {
+		conn, err := t.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go t.proxy(conn)
+	}
+}
+
+// proxy copies the data between the local connection and the current port-forward.
+func (t *tunnel) proxy(conn net.Conn) {
+	defer conn.Close()
+
+	t.mu.Lock()
+	backend := t.backend
+	t.mu.Unlock()
+
+	if backend == "" {
+		return
+	}
+
+	remote, err := net.Dial("tcp", backend)
+	if err != nil {
+		log.Debugf("Cannot connect to the port-forward of %s: %s", t.Name, err)
+
+		return
+	}
+	defer remote.Close()
+
+	t.mu.Lock()
+	t.active++
+	t.total++
+	t.mu.Unlock()
+
+	defer func() {
+		t.mu.Lock()
+		t.active--
+		t.mu.Unlock()
+	}()
+
+	done := make(chan struct{}, 2) //nolint:gomnd
+
+	go func() {
+		_, _ = io.Copy(remote, conn)
+		done <- struct{}{}
+	}()
+
+	go func() {
+		_, _ = io.Copy(conn, remote)
+		done <- struct{}{}
+	}()
+
+	<-done
+}
+
+func (t *tunnel) setState(state, pod string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.state = state
+	if pod != "" {
+		t.pod = pod
+	}
+}
+
+func (t *tunnel) setBackend(backend string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.backend = backend
+}
+
+func (t *tunnel) status() TunnelStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	pod := t.pod
+	if pod == "" {
+		pod = "-"
+	}
+
+	return TunnelStatus{
+		Service:      t.Name,
+		LocalAddress: t.listener.Addr().String(),
+		RemotePort:   t.Service.Port,
+		Pod:          pod,
+		State:        t.state,
+		Active:       t.active,
+		Total:        t.total,
+	}
+}
+
+func sendEvent(events chan<- string, event string) {
+	select {
+	case events <- fmt.Sprintf("%s %s", time.Now().Format("15:04:05"), event):
+	default:
+	}
+}
+
+// showTunnels renders the status of the tunnels in a table until the user quits or the context is canceled.
+func showTunnels(ctx context.Context, tunnels []*tunnel, events <-chan string) error {
+	p := tea.NewProgram(ui.NewTableModel("Port-forwards", []btable.Column{
+		{Title: "Service", Width: 20},
+		{Title: "Local Address", Width: 21},
+		{Title: "Pod", Width: 32},
+		{Title: "State", Width: 12},
+		{Title: "Connections", Width: 12},
+	}))
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	go func() {
+		ticker := time.NewTicker(tunnelRefreshInterval)
+		defer ticker.Stop()
+
+		var pending []string
+
+		for {
+			select {
+			case <-ctx.Done():
+				p.Send(ui.ResultMsg{Ready: true})
+
+				return
+			case e := <-events:
+				pending = append(pending, e)
+			case <-ticker.C:
+				rows := make([]btable.Row, 0, len(tunnels))
+
+				for _, t := range tunnels {
+					s := t.status()
+					rows = append(rows, btable.Row{
+						s.Service, s.LocalAddress, s.Pod, s.State, fmt.Sprintf("%d (%d total)", s.Active, s.Total),
+					})
+				}
+
+				p.Send(ui.TableMsg{Rows: rows, Events: pending})
+
+				pending = nil
+			}
+		}
+	}()
+
+	_, err := p.Run()
+
+	return errors.Wrap(err, "running port-forward status ui")
+}
+
+// logTunnels logs the events of the tunnels until the context is canceled.
+func logTunnels(ctx context.Context, events <-chan string) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-events:
+			log.Info(e)
+		}
+	}
+}
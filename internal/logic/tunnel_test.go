@@ -0,0 +1,111 @@
+package logic
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/config"
+)
+
+type TunnelTestSuite struct {
+	suite.Suite
+}
+
+func TestTunnelTestSuite(t *testing.T) {
+	suite.Run(t, new(TunnelTestSuite))
+}
+
+func (suite *TunnelTestSuite) TestParseLocalPort() {
+	tests := []struct {
+		in      string
+		want    int
+		wantErr bool
+	}{
+		{in: "13306", want: 13306},
+		{in: "auto", want: localPortAuto},
+		{in: "AUTO", want: localPortAuto},
+		{in: "0", wantErr: true},
+		{in: "65536", wantErr: true},
+		{in: "mysql", wantErr: true},
+		{in: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.in, func() {
+			got, err := parseLocalPort(tt.in)
+			if tt.wantErr {
+				suite.Error(err)
+
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, got)
+		})
+	}
+}
+
+func (suite *TunnelTestSuite) TestParseTunnelSpecs() {
+	c := New(config.New("cloud", "reward", "v0.0.1"))
+
+	tests := []struct {
+		name    string
+		args    []string
+		want    []tunnelSpec
+		wantErr string
+	}{
+		{
+			name: "remote ports",
+			args: []string{"db", "Redis"},
+			want: []tunnelSpec{
+				{Name: "db", Service: defaultForwardServices["db"], LocalPort: 3306},
+				{Name: "Redis", Service: defaultForwardServices["redis"], LocalPort: 6379},
+			},
+		},
+		{
+			name: "local ports",
+			args: []string{"db:13306", "opensearch:auto", "elasticsearch:auto"},
+			want: []tunnelSpec{
+				{Name: "db", Service: defaultForwardServices["db"], LocalPort: 13306, explicitLocalPort: true},
+				{
+					Name: "opensearch", Service: defaultForwardServices["opensearch"],
+					LocalPort: localPortAuto, explicitLocalPort: true,
+				},
+				{
+					Name: "elasticsearch", Service: defaultForwardServices["elasticsearch"],
+					LocalPort: localPortAuto, explicitLocalPort: true,
+				},
+			},
+		},
+		{
+			name:    "same local port",
+			args:    []string{"opensearch", "elasticsearch"},
+			wantErr: "opensearch and elasticsearch use the same local port 9200",
+		},
+		{
+			name:    "unknown service",
+			args:    []string{"memcached"},
+			wantErr: `unknown service "memcached"`,
+		},
+		{
+			name:    "invalid local port",
+			args:    []string{"db:mysql"},
+			wantErr: `invalid local port "mysql"`,
+		},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			got, err := c.parseTunnelSpecs(tt.args)
+			if tt.wantErr != "" {
+				suite.ErrorContains(err, tt.wantErr)
+
+				return
+			}
+
+			suite.Require().NoError(err)
+			suite.Equal(tt.want, got)
+		})
+	}
+}
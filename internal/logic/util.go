@@ -2,16 +2,20 @@ package logic
 
 import (
 	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	"gitlab.com/david_mbuvi/go_asterisks"
+
+	"github.com/rewardenv/reward-cloud-cli/internal/ui"
 )
 
 type options struct {
@@ -197,3 +201,91 @@ func GetIDFromPath(path string) string {
 
 	return pathParts[len(pathParts)-1]
 }
+
+// isYes returns true if the answer of a prompt is affirmative.
+func isYes(val string) bool {
+	val = strings.ToLower(strings.TrimSpace(val))
+
+	return val == "y" || val == "yes"
+}
+
+// ParseAge parses a duration which, besides the units supported by time.ParseDuration, accepts days (e.g. 30d)
+// and weeks (e.g. 2w).
+func ParseAge(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if strings.HasSuffix(s, suffix) {
+			i, err := strconv.Atoi(strings.TrimSuffix(s, suffix))
+			if err != nil {
+				return 0, errors.Wrapf(err, "invalid duration: %s", s)
+			}
+
+			return time.Duration(i) * unit, nil
+		}
+	}
+
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid duration: %s", s)
+	}
+
+	return d, nil
+}
+
+// parseDateFlag parses a date in YYYY-MM-DD or RFC3339 format. An empty string results in a zero time. A date
+// without time is the start of the day, or the end of the day if endOfDay is set.
+func parseDateFlag(s string, endOfDay bool) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, nil
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", s, time.Local); err == nil {
+		if endOfDay {
+			return t.AddDate(0, 0, 1).Add(-time.Nanosecond), nil
+		}
+
+		return t, nil
+	}
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, errors.Errorf("invalid date: %s (expected format: YYYY-MM-DD or RFC3339)", s)
+	}
+
+	return t, nil
+}
+
+func formatTime(t *time.Time) string {
+	if t == nil || t.IsZero() {
+		return "-"
+	}
+
+	return t.Local().Format("2006-01-02 15:04:05")
+}
+
+func formatSize(n int64) string {
+	if n < 0 {
+		return "-"
+	}
+
+	return ui.FormatBytes(n)
+}
+
+// detachedContext keeps the values of the context (e.g. the token), but it is not canceled with it. It is used to
+// clean up after an interrupted command.
+type detachedContext struct {
+	context.Context //nolint:containedctx
+}
+
+func (detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (detachedContext) Err() error {
+	return nil
+}
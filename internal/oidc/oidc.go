@@ -0,0 +1,629 @@
+// Package oidc implements the OpenID Connect login used to authenticate against the kubernetes clusters. It supports
+// the authorization code flow with PKCE using a loopback callback server and the device authorization grant for
+// machines without a browser. Tokens are cached on disk and refreshed using the refresh token.
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	FlowAuto    = "auto"
+	FlowBrowser = "browser"
+	FlowDevice  = "device"
+)
+
+// expiryDelta is subtracted from the expiry of the cached tokens, so they are not used right before they expire.
+const expiryDelta = 30 * time.Second
+
+const deviceCodeGrantType = "urn:ietf:params:oauth:grant-type:device_code"
+
+// loopbackPorts are tried in order for the callback server. The redirect URIs of the OIDC clients are registered
+// for these ports.
+var loopbackPorts = []int{8000, 18000}
+
+var defaultScopes = []string{"openid", "email", "profile", "offline_access"}
+
+// ErrNoIDToken is returned when the token response of the provider does not contain an id_token.
+var ErrNoIDToken = errors.New("token response does not contain an id_token")
+
+// Token is the cached result of a login.
+type Token struct {
+	IDToken      string    `json:"id_token"`
+	RefreshToken string    `json:"refresh_token,omitempty"`
+	Expiry       time.Time `json:"expiry"`
+}
+
+// Valid reports whether the id token can still be used.
+func (t *Token) Valid() bool {
+	return t != nil && t.IDToken != "" && time.Now().Add(expiryDelta).Before(t.Expiry)
+}
+
+type options struct {
+	Client       *http.Client
+	ClientSecret string
+	Scopes       []string
+	CacheDir     string
+	Flow         string
+	Out          io.Writer
+	OpenBrowser  func(url string) error
+}
+
+type Option func(*options)
+
+// WithHTTPClient sets the http client used to talk to the provider.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *options) {
+		o.Client = client
+	}
+}
+
+// WithClientSecret sets the secret of confidential clients.
+func WithClientSecret(secret string) Option {
+	return func(o *options) {
+		o.ClientSecret = secret
+	}
+}
+
+// WithCacheDir sets the directory of the token cache. Tokens are not cached if it is empty.
+func WithCacheDir(dir string) Option {
+	return func(o *options) {
+		o.CacheDir = dir
+	}
+}
+
+// WithFlow selects the login flow: FlowAuto, FlowBrowser or FlowDevice. FlowAuto uses the browser flow if a
+// browser can be opened and falls back to the device flow otherwise.
+func WithFlow(flow string) Option {
+	return func(o *options) {
+		o.Flow = flow
+	}
+}
+
+// WithOutput sets the writer of the instructions shown to the user. It defaults to stderr as stdout is reserved
+// for the exec credential.
+func WithOutput(w io.Writer) Option {
+	return func(o *options) {
+		o.Out = w
+	}
+}
+
+// GetToken returns a valid id token for the client of the issuer. The cached token is returned if it is still
+// valid, otherwise it is refreshed or a new login is started.
+func GetToken(ctx context.Context, issuerURL, clientID string, opts ...Option) (*Token, error) {
+	o := &options{
+		Client:      http.DefaultClient,
+		Scopes:      defaultScopes,
+		Flow:        FlowAuto,
+		Out:         os.Stderr,
+		OpenBrowser: openBrowser,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	cacheFile := ""
+	if o.CacheDir != "" {
+		cacheFile = filepath.Join(o.CacheDir, CacheKey(issuerURL, clientID, o.Scopes)+".json")
+	}
+
+	cached, err := readCache(cacheFile)
+	if err != nil {
+		log.Debugf("Ignoring token cache: %s", err)
+	}
+
+	if cached.Valid() {
+		return cached, nil
+	}
+
+	p, err := discover(ctx, o.Client, issuerURL)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &login{provider: p, clientID: clientID, options: o}
+
+	var token *Token
+
+	if cached != nil && cached.RefreshToken != "" {
+		token, err = l.refresh(ctx, cached.RefreshToken)
+		if err != nil {
+			log.Debugf("Cannot refresh token, logging in again: %s", err)
+		}
+	}
+
+	if token == nil {
+		token, err = l.login(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := writeCache(cacheFile, token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+// CacheKey returns the name of the cache file of the issuer, client and scopes.
+func CacheKey(issuerURL, clientID string, scopes []string) string {
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{issuerURL, clientID}, scopes...), "\x00")))
+
+	return hex.EncodeToString(sum[:])
+}
+
+type provider struct {
+	Issuer                      string `json:"issuer"`
+	AuthorizationEndpoint       string `json:"authorization_endpoint"`
+	TokenEndpoint               string `json:"token_endpoint"`
+	DeviceAuthorizationEndpoint string `json:"device_authorization_endpoint"`
+}
+
+func discover(ctx context.Context, client *http.Client, issuerURL string) (*provider, error) {
+	u := strings.TrimSuffix(issuerURL, "/") + "/.well-known/openid-configuration"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, errors.Wrap(err, "creating discovery request")
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, errors.Wrap(err, "discovering oidc provider")
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Errorf("discovering oidc provider: %s", resp.Status)
+	}
+
+	p := &provider{}
+	if err := json.NewDecoder(resp.Body).Decode(p); err != nil {
+		return nil, errors.Wrap(err, "decoding provider configuration")
+	}
+
+	if p.AuthorizationEndpoint == "" || p.TokenEndpoint == "" {
+		return nil, errors.Errorf("provider configuration of %s is incomplete", issuerURL)
+	}
+
+	return p, nil
+}
+
+type login struct {
+	provider *provider
+	clientID string
+	*options
+}
+
+func (l *login) login(ctx context.Context) (*Token, error) {
+	flow := l.Flow
+	if flow == FlowAuto {
+		flow = FlowBrowser
+		if !canOpenBrowser() {
+			flow = FlowDevice
+		}
+	}
+
+	if flow == FlowDevice {
+		if l.provider.DeviceAuthorizationEndpoint == "" {
+			return nil, errors.New("the oidc provider does not support the device authorization grant")
+		}
+
+		return l.deviceCode(ctx)
+	}
+
+	token, err := l.authCode(ctx)
+	if err != nil && l.Flow == FlowAuto && l.provider.DeviceAuthorizationEndpoint != "" {
+		log.Debugf("Browser login failed, falling back to device code: %s", err)
+
+		return l.deviceCode(ctx)
+	}
+
+	return token, err
+}
+
+// authCode runs the authorization code flow with PKCE. The browser is redirected to a callback server listening
+// on the loopback interface.
+func (l *login) authCode(ctx context.Context) (*Token, error) {
+	listener, port, err := listenLoopback()
+	if err != nil {
+		return nil, err
+	}
+	defer listener.Close()
+
+	redirectURI := fmt.Sprintf("http://localhost:%d", port)
+	state := randomString()
+	verifier := randomString()
+
+	authURL := l.provider.AuthorizationEndpoint + "?" + url.Values{
+		"response_type":         {"code"},
+		"client_id":             {l.clientID},
+		"redirect_uri":          {redirectURI},
+		"scope":                 {strings.Join(l.Scopes, " ")},
+		"state":                 {state},
+		"code_challenge":        {CodeChallenge(verifier)},
+		"code_challenge_method": {"S256"},
+	}.Encode()
+
+	type result struct {
+		code string
+		err  error
+	}
+
+	results := make(chan result, 1)
+
+	srv := &http.Server{
+		ReadHeaderTimeout: 10 * time.Second,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			q := r.URL.Query()
+
+			var res result
+
+			switch {
+			case q.Get("state") != state:
+				http.Error(w, "state mismatch", http.StatusBadRequest)
+
+				return
+			case q.Get("error") != "":
+				res.err = errors.Errorf("authorization failed: %s %s", q.Get("error"), q.Get("error_description"))
+			case q.Get("code") == "":
+				http.Error(w, "missing code", http.StatusBadRequest)
+
+				return
+			default:
+				res.code = q.Get("code")
+			}
+
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			if res.err != nil {
+				_, _ = fmt.Fprint(w, "<html><body>Login failed. Please check the terminal.</body></html>")
+			} else {
+				_, _ = fmt.Fprint(w, "<html><body>Logged in. You can close this window.</body></html>")
+			}
+
+			select {
+			case results <- res:
+			default:
+			}
+		}),
+	}
+
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+
+	defer func() {
+		_ = srv.Close()
+	}()
+
+	_, _ = fmt.Fprintf(l.Out, "Opening the browser to log in. If it does not open, visit:\n\n  %s\n\n", authURL)
+
+	if err := l.OpenBrowser(authURL); err != nil {
+		log.Debugf("Cannot open browser: %s", err)
+	}
+
+	var res result
+
+	select {
+	case <-ctx.Done():
+		return nil, errors.Wrap(ctx.Err(), "waiting for the browser login")
+	case res = <-results:
+	}
+
+	if res.err != nil {
+		return nil, res.err
+	}
+
+	return l.token(ctx, url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {res.code},
+		"redirect_uri":  {redirectURI},
+		"code_verifier": {verifier},
+	})
+}
+
+type deviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// deviceCode runs the device authorization grant (RFC 8628). The user finishes the login on another device while
+// the token endpoint is polled.
+func (l *login) deviceCode(ctx context.Context) (*Token, error) {
+	da := &deviceAuthorization{}
+
+	err := l.post(ctx, l.provider.DeviceAuthorizationEndpoint, url.Values{
+		"scope": {strings.Join(l.Scopes, " ")},
+	}, da)
+	if err != nil {
+		return nil, errors.Wrap(err, "requesting device code")
+	}
+
+	verificationURI := da.VerificationURIComplete
+	if verificationURI == "" {
+		verificationURI = da.VerificationURI
+	}
+
+	_, _ = fmt.Fprintf(l.Out, "To log in, visit:\n\n  %s\n\nand enter the code: %s\n\n", verificationURI, da.UserCode)
+
+	interval := time.Duration(da.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	if da.ExpiresIn > 0 {
+		var cancel context.CancelFunc
+
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(da.ExpiresIn)*time.Second)
+		defer cancel()
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil, errors.Wrap(ctx.Err(), "waiting for the device login")
+		case <-time.After(interval):
+		}
+
+		token, err := l.token(ctx, url.Values{
+			"grant_type":  {deviceCodeGrantType},
+			"device_code": {da.DeviceCode},
+		})
+
+		var tokenErr *tokenError
+
+		switch {
+		case err == nil:
+			return token, nil
+		case errors.As(err, &tokenErr) && tokenErr.Code == "authorization_pending":
+		case errors.As(err, &tokenErr) && tokenErr.Code == "slow_down":
+			interval += 5 * time.Second
+		default:
+			return nil, err
+		}
+	}
+}
+
+func (l *login) refresh(ctx context.Context, refreshToken string) (*Token, error) {
+	token, err := l.token(ctx, url.Values{
+		"grant_type":    {"refresh_token"},
+		"refresh_token": {refreshToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Some providers do not rotate the refresh token.
+	if token.RefreshToken == "" {
+		token.RefreshToken = refreshToken
+	}
+
+	return token, nil
+}
+
+type tokenResponse struct {
+	IDToken      string `json:"id_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type tokenError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description"`
+}
+
+func (e *tokenError) Error() string {
+	if e.Description == "" {
+		return e.Code
+	}
+
+	return fmt.Sprintf("%s: %s", e.Code, e.Description)
+}
+
+func (l *login) token(ctx context.Context, params url.Values) (*Token, error) {
+	res := &tokenResponse{}
+	if err := l.post(ctx, l.provider.TokenEndpoint, params, res); err != nil {
+		return nil, err
+	}
+
+	if res.IDToken == "" {
+		return nil, ErrNoIDToken
+	}
+
+	expiry, err := Expiry(res.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Token{
+		IDToken:      res.IDToken,
+		RefreshToken: res.RefreshToken,
+		Expiry:       expiry,
+	}, nil
+}
+
+// post sends a form request authenticated with the client credentials and decodes the JSON response into out.
+// OAuth2 error responses are returned as *tokenError.
+func (l *login) post(ctx context.Context, endpoint string, params url.Values, out interface{}) error {
+	params.Set("client_id", l.clientID)
+	if l.ClientSecret != "" {
+		params.Set("client_secret", l.ClientSecret)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(params.Encode()))
+	if err != nil {
+		return errors.Wrap(err, "creating request")
+	}
+
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := l.Client.Do(req)
+	if err != nil {
+		return errors.Wrapf(err, "POST %s", endpoint)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return errors.Wrap(err, "reading response")
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		tokenErr := &tokenError{}
+		if json.Unmarshal(body, tokenErr) == nil && tokenErr.Code != "" {
+			return tokenErr
+		}
+
+		return errors.Errorf("POST %s: %s", endpoint, resp.Status)
+	}
+
+	return errors.Wrap(json.Unmarshal(body, out), "decoding response")
+}
+
+// CodeChallenge returns the S256 PKCE code challenge of the verifier.
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// Expiry returns the expiration time stored in the exp claim of the JWT.
+func Expiry(jwt string) (time.Time, error) {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 { //nolint:gomnd
+		return time.Time{}, errors.New("malformed id token")
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return time.Time{}, errors.Wrap(err, "decoding id token")
+	}
+
+	claims := struct {
+		Exp int64 `json:"exp"`
+	}{}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return time.Time{}, errors.Wrap(err, "decoding id token claims")
+	}
+
+	if claims.Exp == 0 {
+		return time.Time{}, errors.New("id token has no expiry")
+	}
+
+	return time.Unix(claims.Exp, 0), nil
+}
+
+func readCache(file string) (*Token, error) {
+	if file == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	b, err := os.ReadFile(file)
+	if os.IsNotExist(err) {
+		return nil, nil //nolint:nilnil
+	}
+
+	if err != nil {
+		return nil, errors.Wrap(err, "reading token cache")
+	}
+
+	token := &Token{}
+	if err := json.Unmarshal(b, token); err != nil {
+		return nil, errors.Wrap(err, "decoding token cache")
+	}
+
+	return token, nil
+}
+
+func writeCache(file string, token *Token) error {
+	if file == "" {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(file), 0o700); err != nil {
+		return errors.Wrap(err, "creating token cache directory")
+	}
+
+	b, err := json.Marshal(token)
+	if err != nil {
+		return errors.Wrap(err, "encoding token cache")
+	}
+
+	return errors.Wrap(os.WriteFile(file, b, 0o600), "writing token cache")
+}
+
+func listenLoopback() (net.Listener, int, error) {
+	var err error
+
+	for _, port := range loopbackPorts {
+		var l net.Listener
+
+		l, err = net.Listen("tcp", fmt.Sprintf("127.0.0.1:%d", port))
+		if err == nil {
+			return l, port, nil
+		}
+	}
+
+	return nil, 0, errors.Wrap(err, "starting the callback server")
+}
+
+func randomString() string {
+	b := make([]byte, 32) //nolint:gomnd
+	_, _ = rand.Read(b)
+
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// canOpenBrowser reports whether a browser is likely available, i.e. the session is not remote and there is a
+// display on linux.
+func canOpenBrowser() bool {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return false
+	}
+
+	switch runtime.GOOS {
+	case "darwin", "windows":
+		return true
+	default:
+		return os.Getenv("DISPLAY") != "" || os.Getenv("WAYLAND_DISPLAY") != ""
+	}
+}
+
+func openBrowser(u string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "darwin":
+		cmd = exec.Command("open", u)
+	case "windows":
+		cmd = exec.Command("rundll32", "url.dll,FileProtocolHandler", u)
+	default:
+		cmd = exec.Command("xdg-open", u)
+	}
+
+	return errors.Wrap(cmd.Start(), "opening browser")
+}
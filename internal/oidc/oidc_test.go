@@ -0,0 +1,193 @@
+package oidc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type OIDCTestSuite struct {
+	suite.Suite
+	server *httptest.Server
+	mu     sync.Mutex
+	grants []url.Values
+	// pending is the number of device token polls answered with authorization_pending.
+	pending int
+	dir     string
+}
+
+func TestOIDCTestSuite(t *testing.T) {
+	suite.Run(t, new(OIDCTestSuite))
+}
+
+func (suite *OIDCTestSuite) SetupTest() {
+	suite.grants = nil
+	suite.pending = 1
+	suite.dir = suite.T().TempDir()
+
+	mux := http.NewServeMux()
+	suite.server = httptest.NewServer(mux)
+
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                        suite.server.URL,
+			"authorization_endpoint":        suite.server.URL + "/auth",
+			"token_endpoint":                suite.server.URL + "/token",
+			"device_authorization_endpoint": suite.server.URL + "/device",
+		})
+	})
+
+	mux.HandleFunc("/device", func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"device_code":      "device-code",
+			"user_code":        "ABCD-EFGH",
+			"verification_uri": suite.server.URL + "/verify",
+			"expires_in":       60,
+			"interval":         1,
+		})
+	})
+
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		_ = r.ParseForm()
+
+		suite.mu.Lock()
+		defer suite.mu.Unlock()
+
+		suite.grants = append(suite.grants, r.PostForm)
+
+		if r.PostForm.Get("grant_type") == deviceCodeGrantType && suite.pending > 0 {
+			suite.pending--
+
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = io.WriteString(w, `{"error":"authorization_pending"}`)
+
+			return
+		}
+
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			"id_token":      jwt(time.Now().Add(time.Hour)),
+			"refresh_token": "refresh-" + r.PostForm.Get("grant_type"),
+		})
+	})
+}
+
+func (suite *OIDCTestSuite) TearDownTest() {
+	suite.server.Close()
+}
+
+func (suite *OIDCTestSuite) TestCodeChallenge() {
+	// Test vector from RFC 7636, appendix B.
+	suite.Assert().Equal("E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM",
+		CodeChallenge("dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"))
+}
+
+func (suite *OIDCTestSuite) TestExpiry() {
+	exp := time.Unix(1700000000, 0)
+
+	got, err := Expiry(jwt(exp))
+	suite.Require().NoError(err)
+	suite.Assert().True(exp.Equal(got))
+
+	_, err = Expiry("not-a-jwt")
+	suite.Assert().Error(err)
+}
+
+func (suite *OIDCTestSuite) TestDeviceCodeFlow() {
+	token, err := GetToken(context.Background(), suite.server.URL, "client",
+		WithCacheDir(suite.dir), WithFlow(FlowDevice), WithClientSecret("secret"), WithOutput(io.Discard))
+	suite.Require().NoError(err)
+	suite.Assert().True(token.Valid())
+	suite.Assert().Equal("refresh-"+deviceCodeGrantType, token.RefreshToken)
+
+	suite.Require().Len(suite.grants, 2)
+	suite.Assert().Equal("device-code", suite.grants[1].Get("device_code"))
+	suite.Assert().Equal("secret", suite.grants[1].Get("client_secret"))
+
+	fi, err := os.Stat(filepath.Join(suite.dir, CacheKey(suite.server.URL, "client", defaultScopes)+".json"))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(os.FileMode(0o600), fi.Mode().Perm())
+
+	// The second call is served from the cache.
+	cached, err := GetToken(context.Background(), suite.server.URL, "client",
+		WithCacheDir(suite.dir), WithFlow(FlowDevice), WithOutput(io.Discard))
+	suite.Require().NoError(err)
+	suite.Assert().Equal(token.IDToken, cached.IDToken)
+	suite.Assert().Len(suite.grants, 2)
+}
+
+func (suite *OIDCTestSuite) TestRefresh() {
+	err := writeCache(filepath.Join(suite.dir, CacheKey(suite.server.URL, "client", defaultScopes)+".json"), &Token{
+		IDToken:      jwt(time.Now().Add(-time.Minute)),
+		RefreshToken: "old-refresh-token",
+		Expiry:       time.Now().Add(-time.Minute),
+	})
+	suite.Require().NoError(err)
+
+	token, err := GetToken(context.Background(), suite.server.URL, "client",
+		WithCacheDir(suite.dir), WithFlow(FlowDevice), WithOutput(io.Discard))
+	suite.Require().NoError(err)
+	suite.Assert().True(token.Valid())
+
+	suite.Require().Len(suite.grants, 1)
+	suite.Assert().Equal("refresh_token", suite.grants[0].Get("grant_type"))
+	suite.Assert().Equal("old-refresh-token", suite.grants[0].Get("refresh_token"))
+}
+
+func (suite *OIDCTestSuite) TestAuthCodeFlow() {
+	var authURL *url.URL
+
+	openBrowser := func(o *options) {
+		o.OpenBrowser = func(u string) error {
+			var err error
+
+			authURL, err = url.Parse(u)
+			if err != nil {
+				return err
+			}
+
+			q := authURL.Query()
+
+			go func() {
+				resp, err := http.Get(fmt.Sprintf("%s?code=auth-code&state=%s", //nolint:noctx
+					q.Get("redirect_uri"), url.QueryEscape(q.Get("state"))))
+				if err == nil {
+					resp.Body.Close()
+				}
+			}()
+
+			return nil
+		}
+	}
+
+	token, err := GetToken(context.Background(), suite.server.URL, "client",
+		WithFlow(FlowBrowser), WithOutput(io.Discard), openBrowser)
+	if err != nil && authURL == nil {
+		suite.T().Skipf("cannot start the callback server: %s", err)
+	}
+
+	suite.Require().NoError(err)
+	suite.Assert().True(token.Valid())
+	suite.Assert().Equal("S256", authURL.Query().Get("code_challenge_method"))
+
+	suite.Require().Len(suite.grants, 1)
+	suite.Assert().Equal("auth-code", suite.grants[0].Get("code"))
+	suite.Assert().Equal(authURL.Query().Get("code_challenge"), CodeChallenge(suite.grants[0].Get("code_verifier")))
+}
+
+func jwt(exp time.Time) string {
+	claims, _ := json.Marshal(map[string]int64{"exp": exp.Unix()})
+
+	return "eyJhbGciOiJub25lIn0." + base64.RawURLEncoding.EncodeToString(claims) + ".sig"
+}
@@ -53,14 +53,32 @@ func WithSuppressOutput(b bool) Opt {
 	}
 }
 
+// WithStdin sets the reader used as the standard input of the command instead of os.Stdin.
+func WithStdin(r io.Reader) Opt {
+	return func(c *LocalShell) {
+		c.Stdin = r
+	}
+}
+
+// WithEnv adds environment variables in the form "key=value" to the environment of the command.
+func WithEnv(env ...string) Opt {
+	return func(c *LocalShell) {
+		c.Env = append(c.Env, env...)
+	}
+}
+
 type LocalShell struct {
 	CatchStdout    *bool
 	SuppressStdout *bool
+	Stdin          io.Reader
+	Env            []string
 }
 
 func (c *LocalShell) Reset() {
 	c.CatchStdout = nil
 	c.SuppressStdout = nil
+	c.Stdin = nil
+	c.Env = nil
 }
 
 func (c *LocalShell) ExecuteWithOptions(name string, args []string, opts ...Opt) ([]byte, error) {
@@ -98,6 +116,14 @@ func (c *LocalShell) Execute(name string, arg ...string) ([]byte, error) {
 	cmd.Args = append(cmd.Args, arg...)
 	cmd.Stdin = os.Stdin
 
+	if c.Stdin != nil {
+		cmd.Stdin = c.Stdin
+	}
+
+	if len(c.Env) > 0 {
+		cmd.Env = append(os.Environ(), c.Env...)
+	}
+
 	var combinedOutBuf bytes.Buffer
 
 	switch {
@@ -0,0 +1,434 @@
+// Package strip anonymizes mysqldump output using table and column rules. The dump is processed line by line,
+// so it works on dumps of any size.
+package strip
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	// ActionTruncate removes all rows of the table. The table itself is kept.
+	ActionTruncate = "truncate"
+	// ActionHash replaces the value of the column with its hash.
+	ActionHash = "hash"
+	// ActionFake replaces the value of the column with a deterministic fake value.
+	ActionFake = "fake"
+	// ActionNull sets the column to NULL.
+	ActionNull = "null"
+)
+
+const (
+	FormatEmail = "email"
+	FormatName  = "name"
+	FormatPhone = "phone"
+	FormatText  = "text"
+)
+
+// hashLength is the length of the hashes written to the dump. It is kept short to fit most varchar columns.
+const hashLength = 32
+
+var (
+	ErrInvalidRule     = errors.New("invalid strip rule")
+	ErrUnknownColumns  = errors.New("cannot determine the columns of the table")
+	ErrMalformedInsert = errors.New("malformed insert statement")
+)
+
+// Rule describes what to do with a table or with a column of a table. Table supports glob patterns (e.g. sales_*).
+type Rule struct {
+	Table  string `mapstructure:"table" json:"table" yaml:"table"`
+	Column string `mapstructure:"column" json:"column,omitempty" yaml:"column,omitempty"`
+	Action string `mapstructure:"action" json:"action" yaml:"action"`
+	// Format selects the generator of fake values. If empty it is guessed from the column name.
+	Format string `mapstructure:"format" json:"format,omitempty" yaml:"format,omitempty"`
+}
+
+// Profile is a named set of rules.
+type Profile struct {
+	// ServerStrip requests the server-side strip of the database during the export as well.
+	ServerStrip bool   `mapstructure:"server_strip" json:"serverStrip" yaml:"server_strip"`
+	Rules       []Rule `mapstructure:"rules" json:"rules" yaml:"rules"`
+}
+
+// Validate checks the rules of the profile.
+func (p *Profile) Validate() error {
+	for i, r := range p.Rules {
+		if r.Table == "" {
+			return errors.Wrapf(ErrInvalidRule, "rule %d: table is required", i)
+		}
+
+		if _, err := path.Match(r.Table, ""); err != nil {
+			return errors.Wrapf(ErrInvalidRule, "rule %d: invalid table pattern %q", i, r.Table)
+		}
+
+		switch r.Action {
+		case ActionTruncate:
+			if r.Column != "" {
+				return errors.Wrapf(ErrInvalidRule, "rule %d: %s cannot be used with a column", i, r.Action)
+			}
+		case ActionHash, ActionFake, ActionNull:
+			if r.Column == "" {
+				return errors.Wrapf(ErrInvalidRule, "rule %d: %s requires a column", i, r.Action)
+			}
+		default:
+			return errors.Wrapf(ErrInvalidRule, "rule %d: unknown action %q (options: truncate, hash, fake, null)",
+				i, r.Action)
+		}
+
+		switch r.Format {
+		case "", FormatEmail, FormatName, FormatPhone, FormatText:
+		default:
+			return errors.Wrapf(ErrInvalidRule, "rule %d: unknown format %q (options: email, name, phone, text)",
+				i, r.Format)
+		}
+	}
+
+	return nil
+}
+
+// truncates returns true if the rows of the table have to be removed.
+func (p *Profile) truncates(table string) bool {
+	for _, r := range p.Rules {
+		if r.Action == ActionTruncate && matchTable(r.Table, table) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// columnRules returns the column rules of the table by column name. The first matching rule wins.
+func (p *Profile) columnRules(table string) map[string]Rule {
+	var rules map[string]Rule
+
+	for _, r := range p.Rules {
+		if r.Column == "" || !matchTable(r.Table, table) {
+			continue
+		}
+
+		if rules == nil {
+			rules = make(map[string]Rule)
+		}
+
+		if _, ok := rules[r.Column]; !ok {
+			rules[r.Column] = r
+		}
+	}
+
+	return rules
+}
+
+func matchTable(pattern, table string) bool {
+	ok, _ := path.Match(pattern, table)
+
+	return ok
+}
+
+// Rewrite reads the mysqldump output from src, applies the rules of the profile and writes the result to dst.
+func Rewrite(dst io.Writer, src io.Reader, p *Profile) error {
+	if err := p.Validate(); err != nil {
+		return err
+	}
+
+	r := bufio.NewReaderSize(src, 1024*1024)
+	w := bufio.NewWriterSize(dst, 1024*1024)
+
+	var (
+		columns   = make(map[string][]string)
+		createdIn string
+		skipping  bool
+	)
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return errors.Wrap(err, "reading dump")
+		}
+
+		if line == "" && errors.Is(err, io.EOF) {
+			break
+		}
+
+		out, rewriteErr := func() (string, error) {
+			switch {
+			case skipping:
+				skipping = !statementEnds(line)
+
+				return "", nil
+
+			case createdIn != "":
+				trimmed := strings.TrimSpace(line)
+				if strings.HasPrefix(trimmed, "`") {
+					columns[createdIn] = append(columns[createdIn], identifier(trimmed))
+				} else if strings.HasPrefix(trimmed, ")") {
+					createdIn = ""
+				}
+
+				return line, nil
+
+			case strings.HasPrefix(line, "CREATE TABLE "):
+				createdIn = identifier(strings.TrimPrefix(line, "CREATE TABLE "))
+				columns[createdIn] = nil
+
+				return line, nil
+
+			case strings.HasPrefix(line, "INSERT INTO "), strings.HasPrefix(line, "REPLACE INTO "),
+				strings.HasPrefix(line, "INSERT IGNORE INTO "):
+				return rewriteInsert(line, p, columns, &skipping)
+
+			default:
+				return line, nil
+			}
+		}()
+		if rewriteErr != nil {
+			return rewriteErr
+		}
+
+		if _, err := w.WriteString(out); err != nil {
+			return errors.Wrap(err, "writing dump")
+		}
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+	}
+
+	return errors.Wrap(w.Flush(), "writing dump")
+}
+
+func rewriteInsert(line string, p *Profile, columns map[string][]string, skipping *bool) (string, error) {
+	_, rest, _ := strings.Cut(line, " INTO ")
+	table := identifier(rest)
+
+	if p.truncates(table) {
+		*skipping = !statementEnds(line)
+
+		return "", nil
+	}
+
+	rules := p.columnRules(table)
+	if len(rules) == 0 {
+		return line, nil
+	}
+
+	idx := strings.Index(line, " VALUES ")
+	if idx < 0 {
+		return "", errors.Wrapf(ErrMalformedInsert, "table %s", table)
+	}
+
+	cols := columns[table]
+
+	// INSERT INTO `table` (`a`, `b`) VALUES ...
+	head := line[:idx]
+	if open := strings.Index(head, "("); open >= 0 {
+		cols = nil
+
+		for _, c := range strings.Split(strings.TrimSuffix(strings.TrimSpace(head[open+1:]), ")"), ",") {
+			cols = append(cols, identifier(strings.TrimSpace(c)))
+		}
+	}
+
+	if len(cols) == 0 {
+		return "", errors.Wrapf(ErrUnknownColumns, "table %s", table)
+	}
+
+	actions := make([]*Rule, len(cols))
+
+	for i, c := range cols {
+		if r, ok := rules[c]; ok {
+			r := r
+			actions[i] = &r
+		}
+	}
+
+	values, err := rewriteValues(line[idx+len(" VALUES "):], cols, actions)
+	if err != nil {
+		return "", errors.Wrapf(err, "table %s", table)
+	}
+
+	return line[:idx+len(" VALUES ")] + values, nil
+}
+
+// rewriteValues rewrites the (v1,v2),(v1,v2); part of an insert statement.
+func rewriteValues(s string, cols []string, actions []*Rule) (string, error) {
+	var b strings.Builder
+
+	b.Grow(len(s))
+
+	i := 0
+	for i < len(s) {
+		c := s[i]
+
+		if c != '(' {
+			// separators between the tuples, the closing semicolon and the line ending
+			b.WriteByte(c)
+			i++
+
+			continue
+		}
+
+		b.WriteByte('(')
+		i++
+
+		for field := 0; ; field++ {
+			start := i
+
+			end, err := scanValue(s, i)
+			if err != nil {
+				return "", err
+			}
+
+			raw := s[start:end]
+
+			if field < len(actions) && actions[field] != nil {
+				raw = apply(actions[field], cols[field], raw)
+			}
+
+			b.WriteString(raw)
+
+			if end >= len(s) {
+				return "", ErrMalformedInsert
+			}
+
+			b.WriteByte(s[end])
+			i = end + 1
+
+			if s[end] == ')' {
+				if field+1 != len(cols) {
+					return "", errors.Wrapf(ErrMalformedInsert, "%d values for %d columns", field+1, len(cols))
+				}
+
+				break
+			}
+		}
+	}
+
+	return b.String(), nil
+}
+
+// scanValue returns the index of the comma or closing parenthesis after the value starting at i. Quoted strings
+// are skipped wherever they start, so literals with a prefix (e.g. _binary 'a,b', X'0A' or B'01') are one value.
+func scanValue(s string, i int) (int, error) {
+	for i < len(s) {
+		switch s[i] {
+		case ',', ')':
+			return i, nil
+		case '\'':
+			end, err := scanQuoted(s, i)
+			if err != nil {
+				return 0, err
+			}
+
+			i = end
+		default:
+			i++
+		}
+	}
+
+	return 0, ErrMalformedInsert
+}
+
+// scanQuoted returns the index after the closing quote of the string starting at i.
+func scanQuoted(s string, i int) (int, error) {
+	i++
+
+	for i < len(s) {
+		switch s[i] {
+		case '\\':
+			i += 2
+		case '\'':
+			if i+1 < len(s) && s[i+1] == '\'' {
+				i += 2
+
+				continue
+			}
+
+			return i + 1, nil
+		default:
+			i++
+		}
+	}
+
+	return 0, ErrMalformedInsert
+}
+
+// apply returns the new SQL literal of the value. NULL values are kept as they are.
+func apply(r *Rule, column, raw string) string {
+	if raw == "NULL" || r.Action == ActionNull {
+		return "NULL"
+	}
+
+	sum := sha256.Sum256([]byte(raw))
+	hash := hex.EncodeToString(sum[:])
+
+	if r.Action == ActionHash {
+		return "'" + hash[:hashLength] + "'"
+	}
+
+	format := r.Format
+	if format == "" {
+		format = guessFormat(column)
+	}
+
+	switch format {
+	case FormatEmail:
+		return "'user-" + hash[:12] + "@example.com'"
+	case FormatName:
+		return "'Name " + hash[:8] + "'"
+	case FormatPhone:
+		return "'+1555" + digits(sum[:], 7) + "'"
+	default:
+		return "'fake-" + hash[:12] + "'"
+	}
+}
+
+func guessFormat(column string) string {
+	column = strings.ToLower(column)
+
+	switch {
+	case strings.Contains(column, "email"):
+		return FormatEmail
+	case strings.Contains(column, "phone"), strings.Contains(column, "telephone"), strings.Contains(column, "fax"):
+		return FormatPhone
+	case strings.Contains(column, "name"):
+		return FormatName
+	default:
+		return FormatText
+	}
+}
+
+func digits(b []byte, n int) string {
+	out := make([]byte, n)
+	for i := range out {
+		out[i] = '0' + b[i%len(b)]%10
+	}
+
+	return string(out)
+}
+
+// identifier returns the first (optionally backtick-quoted) identifier of s.
+func identifier(s string) string {
+	s = strings.TrimSpace(s)
+
+	if strings.HasPrefix(s, "`") {
+		if end := strings.Index(s[1:], "`"); end >= 0 {
+			return s[1 : end+1]
+		}
+	}
+
+	if end := strings.IndexAny(s, " (,"); end >= 0 {
+		return s[:end]
+	}
+
+	return s
+}
+
+func statementEnds(line string) bool {
+	return strings.HasSuffix(strings.TrimRight(line, "\r\n"), ";")
+}
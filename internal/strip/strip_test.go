@@ -0,0 +1,140 @@
+package strip
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+const dump = "-- MySQL dump\n" +
+	"DROP TABLE IF EXISTS `customer_entity`;\n" +
+	"CREATE TABLE `customer_entity` (\n" +
+	"  `entity_id` int unsigned NOT NULL AUTO_INCREMENT,\n" +
+	"  `email` varchar(255) DEFAULT NULL,\n" +
+	"  `firstname` varchar(255) DEFAULT NULL,\n" +
+	"  `dob` date DEFAULT NULL,\n" +
+	"  PRIMARY KEY (`entity_id`)\n" +
+	") ENGINE=InnoDB DEFAULT CHARSET=utf8mb3;\n" +
+	"INSERT INTO `customer_entity` VALUES " +
+	"(1,'john@example.org','John, \\'Jr\\'','1990-01-01'),(2,NULL,'Jane','1991-02-02');\n" +
+	"CREATE TABLE `sales_order` (\n" +
+	"  `entity_id` int unsigned NOT NULL AUTO_INCREMENT\n" +
+	") ENGINE=InnoDB;\n" +
+	"INSERT INTO `sales_order` VALUES (1),(2);\n" +
+	"INSERT INTO `store` (`store_id`, `name`) VALUES (1,'Default');\n"
+
+type StripTestSuite struct {
+	suite.Suite
+}
+
+func TestStripTestSuite(t *testing.T) {
+	suite.Run(t, new(StripTestSuite))
+}
+
+func (suite *StripTestSuite) rewrite(p *Profile) string {
+	var out bytes.Buffer
+
+	err := Rewrite(&out, strings.NewReader(dump), p)
+	suite.Require().NoError(err)
+
+	return out.String()
+}
+
+func (suite *StripTestSuite) TestRewriteWithoutRules() {
+	suite.Equal(dump, suite.rewrite(&Profile{}))
+}
+
+func (suite *StripTestSuite) TestRewriteTruncate() {
+	out := suite.rewrite(&Profile{Rules: []Rule{{Table: "sales_*", Action: ActionTruncate}}})
+
+	suite.Contains(out, "CREATE TABLE `sales_order`")
+	suite.NotContains(out, "INSERT INTO `sales_order`")
+	suite.Contains(out, "INSERT INTO `customer_entity`")
+}
+
+func (suite *StripTestSuite) TestRewriteColumns() {
+	out := suite.rewrite(&Profile{Rules: []Rule{
+		{Table: "customer_entity", Column: "email", Action: ActionFake},
+		{Table: "customer_entity", Column: "firstname", Action: ActionHash},
+		{Table: "customer_entity", Column: "dob", Action: ActionNull},
+		{Table: "store", Column: "name", Action: ActionFake, Format: FormatText},
+	}})
+
+	suite.NotContains(out, "john@example.org")
+	suite.NotContains(out, "Jr")
+	suite.NotContains(out, "1990-01-01")
+	suite.Regexp("VALUES \\(1,'user-[0-9a-f]{12}@example.com','[0-9a-f]{32}',NULL\\),\\(2,NULL,'[0-9a-f]{32}',NULL\\);\n",
+		out)
+	suite.Regexp("VALUES \\(1,'fake-[0-9a-f]{12}'\\);\n", out)
+	suite.Contains(out, "INSERT INTO `sales_order` VALUES (1),(2);\n")
+}
+
+func (suite *StripTestSuite) TestRewriteIsDeterministic() {
+	p := &Profile{Rules: []Rule{{Table: "customer_entity", Column: "email", Action: ActionFake}}}
+
+	suite.Equal(suite.rewrite(p), suite.rewrite(p))
+}
+
+func (suite *StripTestSuite) TestRewritePrefixedLiterals() {
+	in := "CREATE TABLE `customer_entity` (\n" +
+		"  `entity_id` int,\n" +
+		"  `data` blob,\n" +
+		"  `flags` bit(8),\n" +
+		"  `email` varchar(255)\n" +
+		");\n" +
+		"INSERT INTO `customer_entity` VALUES (1,_binary 'a,b',B'0101',X'2C29'),(2,X'2C',b'1','john@example.org');\n"
+
+	p := &Profile{Rules: []Rule{
+		{Table: "customer_entity", Column: "flags", Action: ActionNull},
+		{Table: "customer_entity", Column: "email", Action: ActionFake, Format: FormatEmail},
+	}}
+
+	var out bytes.Buffer
+
+	suite.Require().NoError(Rewrite(&out, strings.NewReader(in), p))
+	suite.NotContains(out.String(), "john@example.org")
+	suite.Regexp("VALUES \\(1,_binary 'a,b',NULL,'user-[0-9a-f]{12}@example.com'\\),"+
+		"\\(2,X'2C',NULL,'user-[0-9a-f]{12}@example.com'\\);\n", out.String())
+}
+
+func (suite *StripTestSuite) TestRewriteFieldCountMismatch() {
+	in := "CREATE TABLE `customer_entity` (\n" +
+		"  `entity_id` int,\n" +
+		"  `email` varchar(255)\n" +
+		");\n" +
+		"INSERT INTO `customer_entity` VALUES (1,'a','john@example.org');\n"
+
+	p := &Profile{Rules: []Rule{{Table: "customer_entity", Column: "email", Action: ActionHash}}}
+
+	err := Rewrite(&bytes.Buffer{}, strings.NewReader(in), p)
+	suite.ErrorIs(err, ErrMalformedInsert)
+}
+
+func (suite *StripTestSuite) TestValidate() {
+	tests := []struct {
+		name    string
+		rule    Rule
+		wantErr bool
+	}{
+		{name: "valid truncate", rule: Rule{Table: "sales_order", Action: ActionTruncate}},
+		{name: "valid column rule", rule: Rule{Table: "customer_*", Column: "email", Action: ActionHash}},
+		{name: "missing table", rule: Rule{Action: ActionTruncate}, wantErr: true},
+		{name: "truncate with column", rule: Rule{Table: "t", Column: "c", Action: ActionTruncate}, wantErr: true},
+		{name: "hash without column", rule: Rule{Table: "t", Action: ActionHash}, wantErr: true},
+		{name: "unknown action", rule: Rule{Table: "t", Column: "c", Action: "shuffle"}, wantErr: true},
+		{name: "unknown format", rule: Rule{Table: "t", Column: "c", Action: ActionFake, Format: "iban"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		suite.Run(tt.name, func() {
+			err := (&Profile{Rules: []Rule{tt.rule}}).Validate()
+			if tt.wantErr {
+				suite.Error(err)
+			} else {
+				suite.NoError(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package ui
+
+import (
+	"fmt"
+
+	"github.com/charmbracelet/bubbles/progress"
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// ProgressMsg reports the number of bytes transferred. Total is -1 if the size is unknown.
+type ProgressMsg struct {
+	Written int64
+	Total   int64
+}
+
+type ProgressModel struct {
+	msg      string
+	progress progress.Model
+	written  int64
+	total    int64
+	quitting bool
+}
+
+func NewProgressModel(msg string) ProgressModel {
+	return ProgressModel{
+		msg:      msg,
+		progress: progress.New(progress.WithDefaultGradient(), progress.WithWidth(50)),
+		total:    -1,
+	}
+}
+
+func (m ProgressModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m ProgressModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.quitting = true
+
+			return m, tea.Quit
+		default:
+			return m, nil
+		}
+
+	case ResultMsg:
+		if msg.Ready {
+			m.quitting = true
+
+			return m, tea.Quit
+		}
+
+		return m, nil
+
+	case ProgressMsg:
+		m.written = msg.Written
+		m.total = msg.Total
+
+		return m, nil
+
+	default:
+		return m, nil
+	}
+}
+
+func (m ProgressModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	s := fmt.Sprintf("%s\n\n", m.msg)
+
+	if m.total > 0 {
+		percent := float64(m.written) / float64(m.total)
+		if percent > 1 {
+			percent = 1
+		}
+
+		s += fmt.Sprintf("%s  %s / %s\n",
+			m.progress.ViewAs(percent),
+			FormatBytes(m.written),
+			FormatBytes(m.total),
+		)
+	} else {
+		s += fmt.Sprintf("%s\n", FormatBytes(m.written))
+	}
+
+	s += helpStyle.Render("Press q to quit")
+
+	return appStyle.Render(s)
+}
+
+// FormatBytes returns the human-readable representation of n bytes.
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
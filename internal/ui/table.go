@@ -0,0 +1,134 @@
+package ui
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/table"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+)
+
+const numLastEvents = 5
+
+var errorStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("196"))
+
+// TableMsg replaces the rows of the table. Events are appended to the list of the last events, Err is shown
+// below the table until the next successful refresh.
+type TableMsg struct {
+	Rows   []table.Row
+	Events []string
+	Err    error
+}
+
+// TableModel renders a table which is refreshed by TableMsg messages.
+type TableModel struct {
+	msg      string
+	table    table.Model
+	events   []string
+	updated  time.Time
+	err      error
+	quitting bool
+}
+
+func NewTableModel(msg string, columns []table.Column) TableModel {
+	t := table.New(
+		table.WithColumns(columns),
+		table.WithFocused(true),
+		table.WithHeight(10),
+	)
+
+	s := table.DefaultStyles()
+	s.Header = s.Header.
+		BorderStyle(lipgloss.NormalBorder()).
+		BorderBottom(true).
+		Bold(true)
+	s.Selected = s.Selected.
+		Foreground(lipgloss.Color("229")).
+		Background(lipgloss.Color("57"))
+	t.SetStyles(s)
+
+	return TableModel{
+		msg:   msg,
+		table: t,
+	}
+}
+
+func (m TableModel) Init() tea.Cmd {
+	return nil
+}
+
+func (m TableModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "esc", "ctrl+c":
+			m.quitting = true
+
+			return m, tea.Quit
+		default:
+			var cmd tea.Cmd
+			m.table, cmd = m.table.Update(msg)
+
+			return m, cmd
+		}
+
+	case ResultMsg:
+		if msg.Ready {
+			m.quitting = true
+
+			return m, tea.Quit
+		}
+
+		return m, nil
+
+	case TableMsg:
+		m.err = msg.Err
+		if msg.Err != nil {
+			return m, nil
+		}
+
+		m.updated = time.Now()
+		m.table.SetRows(msg.Rows)
+		m.table.SetHeight(len(msg.Rows) + 1)
+
+		m.events = append(m.events, msg.Events...)
+		if len(m.events) > numLastEvents {
+			m.events = m.events[len(m.events)-numLastEvents:]
+		}
+
+		return m, nil
+
+	default:
+		return m, nil
+	}
+}
+
+func (m TableModel) View() string {
+	if m.quitting {
+		return ""
+	}
+
+	s := m.msg
+	if !m.updated.IsZero() {
+		s += durationStyle.Render(fmt.Sprintf(" (updated at %s)", m.updated.Format("15:04:05")))
+	}
+
+	s += "\n\n" + m.table.View() + "\n"
+
+	if len(m.events) > 0 {
+		s += "\n"
+
+		for _, e := range m.events {
+			s += dotStyle.Render(e) + "\n"
+		}
+	}
+
+	if m.err != nil {
+		s += "\n" + errorStyle.Render(m.err.Error()) + "\n"
+	}
+
+	s += helpStyle.Render("Press q to quit")
+
+	return appStyle.Render(s)
+}